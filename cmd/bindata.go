@@ -86,6 +86,8 @@ func BindataCmd() cli.Command {
 				Name:  inputFlag,
 				Usage: "List of input directories/files",
 			},
+			checkBoolFlag,
+			noCacheBoolFlag,
 		},
 		Action: bindataAction,
 	}
@@ -115,6 +117,8 @@ func bindataConfig(c *cli.Context) *configs.BindataConfig {
 		Output:     c.String(outputFlag),
 		Mode:       c.Uint(modeFlag),
 		ModTime:    c.Int64(modetimeFlag),
+		Check:      c.Bool(checkFlag),
+		NoCache:    c.Bool(noCacheFlag),
 	}
 	if cfg.Output == "" {
 		cfg.Output = "./bindata.go"
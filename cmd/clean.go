@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/generate"
+)
+
+func CleanCmd() cli.Command {
+	return cli.Command{
+		Name:      "clean",
+		Usage:     "remove the stale files generated by forge enum for renamed, removed or regenerated types",
+		ArgsUsage: "[dir | dir/...]",
+		Flags: []cli.Flag{
+			checkBoolFlag,
+			formatStringFlag,
+		},
+		Action: reportErrors(cleanAction),
+	}
+}
+
+func cleanAction(c *cli.Context) error {
+	dir := "."
+	if c.NArg() > 0 {
+		dir = c.Args().First()
+	}
+
+	return generate.Clean(configs.CleanConfig{
+		Dir:   dir,
+		Check: c.Bool(checkFlag),
+	})
+}
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/urfave/cli"
 
 	"github.com/lancer-kit/forge/configs"
 	"github.com/lancer-kit/forge/generate"
+	"github.com/lancer-kit/forge/parser"
 	"github.com/lancer-kit/forge/templates"
 )
 
@@ -12,6 +15,8 @@ func EnumCmd() cli.Command {
 	return cli.Command{
 		Name:  "enum",
 		Usage: "generate var and methods for the iota-enums",
+		ArgsUsage: "[dir | dir/...]; without --type the types " +
+			"marked with the //forge:enum directive are generated",
 		Flags: append(baseFlags,
 
 			cli.StringFlag{
@@ -24,28 +29,66 @@ func EnumCmd() cli.Command {
 				Name:  tprefixFlag,
 				Usage: "keep typename prefix in string values or not;",
 			},
+
+			cli.StringFlag{
+				Name:  outputsFlag,
+				Usage: "list of output groups to generate (json, sql, gql, xml, bson);",
+				Value: strings.Join(templates.DefaultEnumOutputs, ","),
+			},
+
+			cli.StringFlag{
+				Name:  duplicatesFlag,
+				Usage: "which of constants with the same value is used as a string (error, first, last);",
+				Value: string(parser.DuplicateFirst),
+			},
+
+			cli.StringFlag{
+				Name: lookupFlag,
+				Usage: "how the generated code finds the string of value (map, index, switch, auto), " +
+					"index is applicable only for contiguous values, auto chooses index or switch;",
+				Value: string(templates.LookupMap),
+			},
+
+			cli.StringFlag{
+				Name:  bsonTagFlag,
+				Usage: "build tag of the files with BSON marshaling methods;",
+				Value: generate.DefaultBSONBuildTag,
+			},
+
+			cleanBoolFlag,
+			checkBoolFlag,
+			noCacheBoolFlag,
+			formatStringFlag,
 		),
-		Action: enumsAction,
+		Action: reportErrors(enumsAction),
 	}
 }
 
 func enumsAction(c *cli.Context) error {
 	config := enumsConfig(c)
-	err := config.Validate()
-	if err != nil {
-		return cli.NewExitError("ERROR: "+err.Error(), 1)
-	}
-	err = generate.Enums(config)
-	if err != nil {
-		return cli.NewExitError("ERROR: "+err.Error(), 1)
+	if err := config.Validate(); err != nil {
+		return err
 	}
-	return nil
+	return generate.Enums(config)
 }
 
 func enumsConfig(c *cli.Context) configs.EnumsConfig {
+	// the empty list is kept to generate none of the outputs, nil is the default ones
+	outputs := []string{}
+	if raw := c.String(outputsFlag); raw != "" {
+		outputs = strings.Split(raw, ",")
+	}
+
 	return configs.EnumsConfig{
-		BaseConfig:    baseConfig(c),
-		TransformRule: templates.TransformRule(c.String(transformFlag)),
-		AddTypePrefix: c.Bool(tprefixFlag),
+		BaseConfig:   baseConfig(c),
+		BSONBuildTag: c.String(bsonTagFlag),
+		Clean:        c.Bool(cleanFlag),
+		EnumOptions: configs.EnumOptions{
+			TransformRule:   templates.TransformRule(c.String(transformFlag)),
+			AddTypePrefix:   c.Bool(tprefixFlag),
+			Outputs:         outputs,
+			DuplicatePolicy: parser.DuplicatePolicy(c.String(duplicatesFlag)),
+			Lookup:          templates.LookupMode(c.String(lookupFlag)),
+		},
 	}
 }
@@ -5,21 +5,57 @@ import (
 )
 
 const (
-	typesFlag     = "type"
-	mergeFlag     = "merge"
-	suffixFlag    = "suffix"
-	prefixFlag    = "prefix"
-	dirFlag       = "dir"
-	nameFlag      = "name"
-	transformFlag = "transform"
-	tprefixFlag   = "tprefix"
-	tmplFlag      = "tmpl"
+	typesFlag      = "type"
+	mergeFlag      = "merge"
+	suffixFlag     = "suffix"
+	prefixFlag     = "prefix"
+	dirFlag        = "dir"
+	nameFlag       = "name"
+	transformFlag  = "transform"
+	tprefixFlag    = "tprefix"
+	tmplFlag       = "tmpl"
+	outputsFlag    = "outputs"
+	duplicatesFlag = "duplicates"
+	bsonTagFlag    = "bson-tag"
+	lookupFlag     = "lookup"
+	checkFlag      = "check"
+	noCacheFlag    = "no-cache"
+	formatFlag     = "format"
+	cleanFlag      = "clean"
+	flattenFlag    = "flatten"
 )
 
+// Formats of the errors.
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+var checkBoolFlag = cli.BoolFlag{
+	Name:  checkFlag,
+	Usage: "check that generated files are up to date without writing them, print the diff otherwise;",
+}
+
+var noCacheBoolFlag = cli.BoolFlag{
+	Name:  noCacheFlag,
+	Usage: "don't use the cache of the generated files;",
+}
+
+var cleanBoolFlag = cli.BoolFlag{
+	Name:  cleanFlag,
+	Usage: "remove the stale files generated for the types earlier;",
+}
+
+var formatStringFlag = cli.StringFlag{
+	Name:  formatFlag,
+	Usage: "format of the errors (text, json), json diagnostics are printed to stdout;",
+	Value: formatText,
+}
+
 var baseFlags = []cli.Flag{
 	cli.StringFlag{
 		Name:  typesFlag,
-		Usage: "list of type names; required;",
+		Usage: "list of type names;",
 	},
 	cli.StringFlag{
 		Name:  prefixFlag,
@@ -37,4 +73,16 @@ var baseFlags = []cli.Flag{
 		Name:  mergeFlag,
 		Usage: "merge all output into one file;",
 	},
+
+	nameStringFlag,
+}
+
+var nameStringFlag = cli.StringFlag{
+	Name:  nameFlag,
+	Usage: "name of the output file, replaces prefix and suffix; requires single type or merge;",
+}
+
+var dirStringFlag = cli.StringFlag{
+	Name:  dirFlag,
+	Usage: "directory of the output files, relative to the package directory;",
 }
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/generate"
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+const optFlag = "opt"
+
+func GenCmd() cli.Command {
+	return cli.Command{
+		Name:      "gen",
+		Usage:     "run the external generator " + generate.PluginPrefix + "<name> found in the PATH",
+		ArgsUsage: "<name> [dir]; flags follow the name and precede the dir",
+		// flags are parsed after the plugin name, see genAction
+		SkipFlagParsing: true,
+		Flags:           genFlags,
+		Action:          genAction,
+	}
+}
+
+var genFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  typesFlag,
+		Usage: "list of type names; required;",
+	},
+	cli.StringFlag{
+		Name:  transformFlag,
+		Usage: "way to convert constants of enum types to a string;",
+		Value: "none",
+	},
+	cli.BoolFlag{
+		Name:  tprefixFlag,
+		Usage: "keep typename prefix in string values or not;",
+	},
+	cli.StringFlag{
+		Name:  duplicatesFlag,
+		Usage: "which of constants with the same value is used as a string (error, first, last);",
+		Value: string(parser.DuplicateFirst),
+	},
+	cli.StringSliceFlag{
+		Name:  optFlag,
+		Usage: "option passed to the plugin as key=value, can be repeated;",
+	},
+	checkBoolFlag,
+	formatStringFlag,
+}
+
+func genAction(c *cli.Context) error {
+	name := c.Args().First()
+	if name == "" || strings.HasPrefix(name, "-") {
+		return cli.ShowCommandHelp(c, "gen")
+	}
+
+	flags, err := parseFlags("gen", genFlags, c.Args().Tail())
+	if err != nil {
+		return cli.NewExitError("ERROR: "+err.Error(), 1)
+	}
+	// parsing stops at the directory, so the flags following it would be ignored
+	for _, arg := range flags.Args() {
+		if strings.HasPrefix(arg, "-") {
+			return cli.NewExitError(fmt.Sprintf("ERROR: flag %s follows the directory, flags should precede it", arg), 1)
+		}
+	}
+	if flags.NArg() > 1 {
+		return cli.NewExitError("ERROR: only one directory can be processed", 1)
+	}
+	return reportErrors(func(c *cli.Context) error {
+		config, err := pluginConfig(name, c)
+		if err != nil {
+			return err
+		}
+		if err := config.Validate(); err != nil {
+			return err
+		}
+		return generate.Plugin(config)
+	})(flags)
+}
+
+func pluginConfig(name string, c *cli.Context) (configs.PluginConfig, error) {
+	options := map[string]string{}
+	for _, opt := range c.StringSlice(optFlag) {
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return configs.PluginConfig{}, fmt.Errorf("opt: should be key=value, got %q", opt)
+		}
+		options[kv[0]] = kv[1]
+	}
+
+	return configs.PluginConfig{
+		BaseConfig:      baseConfig(c),
+		Name:            name,
+		TransformRule:   templates.TransformRule(c.String(transformFlag)),
+		AddTypePrefix:   c.Bool(tprefixFlag),
+		DuplicatePolicy: parser.DuplicatePolicy(c.String(duplicatesFlag)),
+		Options:         options,
+	}, nil
+}
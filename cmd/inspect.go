@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/generate"
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+const formatTable = "table"
+
+func InspectCmd() cli.Command {
+	return cli.Command{
+		Name:      "inspect",
+		Usage:     "list the enum and struct types of the package and the status of the enum templates",
+		ArgsUsage: "[dir | dir/...]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  formatFlag,
+				Usage: "output format (table, json);",
+				Value: formatTable,
+			},
+			cli.StringFlag{
+				Name:  transformFlag,
+				Usage: "way to convert constants to a string;",
+				Value: "none",
+			},
+			cli.BoolFlag{
+				Name:  tprefixFlag,
+				Usage: "keep typename prefix in string values or not;",
+			},
+			cli.StringFlag{
+				Name:  outputsFlag,
+				Usage: "list of output groups to generate (json, sql, gql, xml, bson);",
+				Value: strings.Join(templates.DefaultEnumOutputs, ","),
+			},
+			cli.StringFlag{
+				Name:  duplicatesFlag,
+				Usage: "which of constants with the same value is used as a string (error, first, last);",
+				Value: string(parser.DuplicateFirst),
+			},
+		},
+		Action: inspectAction,
+	}
+}
+
+func inspectAction(c *cli.Context) error {
+	format := c.String(formatFlag)
+	if format != formatTable && format != formatJSON {
+		return cli.NewExitError("ERROR: format: should be table or json", 1)
+	}
+
+	config := inspectConfig(c)
+	if err := config.Validate(); err != nil {
+		return cli.NewExitError("ERROR: "+err.Error(), 1)
+	}
+
+	inspections, err := generate.Inspect(config)
+	if err != nil {
+		return cli.NewExitError("ERROR: "+err.Error(), 1)
+	}
+
+	if format == formatJSON {
+		raw, err := json.MarshalIndent(inspections, "", "  ")
+		if err != nil {
+			return cli.NewExitError("ERROR: "+err.Error(), 1)
+		}
+		fmt.Println(string(raw))
+		return nil
+	}
+
+	for i, inspection := range inspections {
+		if i > 0 {
+			fmt.Println()
+		}
+		printInspection(os.Stdout, inspection)
+	}
+	return nil
+}
+
+func inspectConfig(c *cli.Context) configs.InspectConfig {
+	// the empty list is kept to generate none of the outputs, nil is the default ones
+	outputs := []string{}
+	if raw := c.String(outputsFlag); raw != "" {
+		outputs = strings.Split(raw, ",")
+	}
+
+	dir := "."
+	if c.NArg() > 0 {
+		dir = c.Args().First()
+	}
+
+	return configs.InspectConfig{
+		Dir: dir,
+		EnumOptions: configs.EnumOptions{
+			TransformRule:   templates.TransformRule(c.String(transformFlag)),
+			AddTypePrefix:   c.Bool(tprefixFlag),
+			Outputs:         outputs,
+			DuplicatePolicy: parser.DuplicatePolicy(c.String(duplicatesFlag)),
+			Lookup:          templates.LookupMap,
+		},
+	}
+}
+
+// printInspection prints the inspection of the package as the tables.
+func printInspection(out io.Writer, inspection generate.Inspection) {
+	fmt.Fprintf(out, "package %s (%s)\n", inspection.Package, inspection.Dir)
+	if len(inspection.Generated) > 0 {
+		fmt.Fprintf(out, "generated files, excluded: %s\n", strings.Join(inspection.Generated, ", "))
+	}
+
+	for _, enum := range inspection.Enums {
+		directive := ""
+		if enum.Directive {
+			directive = ", " + parser.EnumDirective
+		}
+		fmt.Fprintf(out, "\nenum %s (%s%s) %s\n", enum.Name, enum.Underlying, directive, enum.Pos)
+		if enum.Error != "" {
+			fmt.Fprintf(out, "  error: %s\n", strings.Replace(enum.Error, "\n", "\n    ", -1))
+		}
+
+		if len(enum.Values) > 0 {
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "  CONSTANT\tVALUE\tSTRING\tALIAS OF")
+			for _, value := range enum.Values {
+				fmt.Fprintf(w, "  %s\t%s\t%q\t%s\n", value.Name, value.Value, value.Str, value.AliasOf)
+			}
+			w.Flush()
+		}
+		if len(enum.Templates) > 0 {
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "  TEMPLATE\tSTATUS\tREASON")
+			for _, tmpl := range enum.Templates {
+				fmt.Fprintf(w, "  %s\t%s\t%s\n", tmpl.Name, tmpl.Status, tmpl.Reason)
+			}
+			w.Flush()
+		}
+	}
+
+	for _, st := range inspection.Structs {
+		fmt.Fprintf(out, "\nstruct %s %s\n", st.Name, st.Pos)
+		if st.Error != "" {
+			fmt.Fprintf(out, "  error: %s\n", strings.Replace(st.Error, "\n", "\n    ", -1))
+		}
+
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "  FIELD\tTYPE\tTAG\tEMBEDDED")
+		for _, field := range st.Fields {
+			embedded := ""
+			if field.Embedded {
+				embedded = "yes"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", field.Name, field.Type, field.Tag, embedded)
+		}
+		w.Flush()
+		if len(st.Methods) > 0 {
+			fmt.Fprintf(out, "  methods: %s\n", strings.Join(st.Methods, ", "))
+		}
+	}
+}
@@ -1,20 +1,134 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
 	"strings"
 
 	"github.com/urfave/cli"
 
 	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/generate"
 )
 
+// FixBoolArgs joins the bool flags with their values passed as the separate arguments,
+// like `--merge true` of the old go:generate lines, and warns that the form is deprecated.
+// Otherwise the value is taken as the dir and the flags following it are ignored.
+func FixBoolArgs(commands cli.Commands, args []string) []string {
+	if len(args) < 2 {
+		return args
+	}
+	boolFlags := map[string]bool{}
+	for _, command := range commands {
+		if !command.HasName(args[1]) {
+			continue
+		}
+		for _, f := range command.Flags {
+			if _, ok := f.(cli.BoolFlag); ok {
+				boolFlags[f.GetName()] = true
+			}
+		}
+	}
+	if len(boolFlags) == 0 {
+		return args
+	}
+
+	result := append([]string{}, args[:2]...)
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		name := strings.TrimLeft(arg, "-")
+		if strings.HasPrefix(arg, "-") && boolFlags[name] && i+1 < len(args) && (args[i+1] == "true" || args[i+1] == "false") {
+			log.Printf("[WARN] %s %s is deprecated, use %s=%s: the value separated by a space is taken as the dir\n",
+				arg, args[i+1], arg, args[i+1])
+			arg += "=" + args[i+1]
+			i++
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
 func baseConfig(c *cli.Context) configs.BaseConfig {
+	var types []string
+	if raw := c.String(typesFlag); raw != "" {
+		types = strings.Split(raw, ",")
+	}
+
+	dir := "."
+	if c.NArg() > 0 {
+		dir = c.Args().First()
+	}
+
 	return configs.BaseConfig{
-		Types:        strings.Split(c.String(typesFlag), ","),
+		Dir:          dir,
+		Types:        types,
 		MergeSpecs:   c.Bool(mergeFlag),
 		OutputPrefix: c.String(prefixFlag),
 		OutputSuffix: c.String(suffixFlag),
 		OutputDir:    c.String(dirFlag),
 		OutputName:   c.String(nameFlag),
+		Check:        c.Bool(checkFlag),
+		NoCache:      c.Bool(noCacheFlag),
+		Command:      commandLine(),
+	}
+}
+
+// commandLine returns the forge command written into the headers of the generated
+// files. Flags, which don't affect the content (like check), are omitted,
+// so the check mode renders exactly the same output as the regular run.
+func commandLine() string {
+	var args []string
+	osArgs := os.Args[1:]
+	for i := 0; i < len(osArgs); i++ {
+		arg := osArgs[i]
+		if strings.HasPrefix(arg, "-") {
+			name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)
+			switch name[0] {
+			case checkFlag, noCacheFlag, cleanFlag:
+				continue
+			case formatFlag:
+				if len(name) == 1 {
+					// the value is the next argument
+					i++
+				}
+				continue
+			}
+		}
+		args = append(args, arg)
+	}
+	return strings.Join(args, " ")
+}
+
+// reportErrors wraps the action to report its errors in the format set by the format flag:
+// as text or as JSON diagnostics printed to stdout, which are empty on success.
+func reportErrors(action cli.ActionFunc) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		format := c.String(formatFlag)
+		if format != formatText && format != formatJSON {
+			return cli.NewExitError("ERROR: format: should be text or json", 1)
+		}
+
+		err := action(c)
+		if format == formatText {
+			if err != nil {
+				return cli.NewExitError("ERROR: "+err.Error(), 1)
+			}
+			return nil
+		}
+
+		raw, jsonErr := json.MarshalIndent(generate.DiagnosticsOf(err), "", "  ")
+		if jsonErr != nil {
+			return cli.NewExitError("ERROR: "+jsonErr.Error(), 1)
+		}
+		fmt.Println(string(raw))
+		if err != nil {
+			return cli.NewExitError("", 1)
+		}
+		return nil
 	}
 }
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/generate"
+)
+
+func MigrationCmd() cli.Command {
+	return cli.Command{
+		Name:      "migration",
+		Usage:     "generate sql-migrate migration of the tables by the db tags of the structures",
+		ArgsUsage: "[dir]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  typesFlag,
+				Usage: "list of type names; required;",
+			},
+			cli.StringFlag{
+				Name:  dirFlag,
+				Usage: "directory of the migrations, relative to the package directory;",
+				Value: configs.DefaultMigrationsDir,
+			},
+			cli.StringFlag{
+				Name:  nameFlag,
+				Usage: "name of the migration following the timestamp, like add_email; requires single type;",
+			},
+			checkBoolFlag,
+			formatStringFlag,
+		},
+		Action: reportErrors(migrationAction),
+	}
+}
+
+func migrationAction(c *cli.Context) error {
+	config := configs.MigrationConfig{BaseConfig: baseConfig(c)}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	return generate.Migration(config)
+}
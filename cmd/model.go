@@ -14,9 +14,9 @@ func ModelCmd() cli.Command {
 		Name:  "model",
 		Usage: "generate code for structure by template",
 		Flags: []cli.Flag{
-			cli.StringFlag{
+			cli.StringSliceFlag{
 				Name:  tPath,
-				Usage: "path to the templates; required;",
+				Usage: "path to the template or the directory of templates; can be repeated; required;",
 			},
 			cli.StringFlag{
 				Name:  typesFlag,
@@ -33,28 +33,32 @@ func ModelCmd() cli.Command {
 				Usage: "suffix to be added to the output file;",
 				Value: "",
 			},
+			dirStringFlag,
+			nameStringFlag,
+			cli.BoolFlag{
+				Name:  flattenFlag,
+				Usage: "replace the embedded struct fields by the promoted ones;",
+			},
+			checkBoolFlag,
+			noCacheBoolFlag,
+			formatStringFlag,
 		},
-		Action: modelAction,
+		Action: reportErrors(modelAction),
 	}
 }
 
 func modelAction(c *cli.Context) error {
 	config := modelConfig(c)
 	if err := config.Validate(); err != nil {
-		return cli.NewExitError("[ERROR] "+err.Error(), 1)
+		return err
 	}
-
-	err := generate.Model(config)
-	if err != nil {
-		return cli.NewExitError("[ERROR] "+err.Error(), 1)
-	}
-
-	return nil
+	return generate.Model(config)
 }
 
 func modelConfig(c *cli.Context) configs.ModelConfig {
 	return configs.ModelConfig{
 		BaseConfig: baseConfig(c),
-		TPath:      c.String(tmplFlag),
+		TPaths:     c.StringSlice(tmplFlag),
+		Flatten:    c.Bool(flattenFlag),
 	}
 }
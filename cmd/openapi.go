@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/generate"
+)
+
+func OpenAPICmd() cli.Command {
+	return cli.Command{
+		Name:      "openapi",
+		Usage:     "generate OpenAPI 3 schemas of the structures and merge them into the openapi.yaml",
+		ArgsUsage: "[dir]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  typesFlag,
+				Usage: "list of type names; all structures of the package by default;",
+			},
+			dirStringFlag,
+			cli.StringFlag{
+				Name:  nameFlag,
+				Usage: "name of the OpenAPI document;",
+				Value: configs.DefaultOpenAPIName,
+			},
+			checkBoolFlag,
+			formatStringFlag,
+		},
+		Action: reportErrors(openAPIAction),
+	}
+}
+
+func openAPIAction(c *cli.Context) error {
+	config := configs.OpenAPIConfig{BaseConfig: baseConfig(c)}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	return generate.OpenAPI(config)
+}
@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/generate"
+)
+
+const (
+	fileFlag     = "file"
+	parallelFlag = "parallel"
+)
+
+var jobsFileFlag = cli.StringFlag{
+	Name:  fileFlag,
+	Usage: "path to the jobs file, paths of the jobs are relative to its directory;",
+	Value: configs.DefaultJobsFile,
+}
+
+var parallelIntFlag = cli.IntFlag{
+	Name:  parallelFlag,
+	Usage: "maximum number of the jobs running at the same time;",
+	Value: runtime.NumCPU(),
+}
+
+func RunCmd() cli.Command {
+	return cli.Command{
+		Name:      "run",
+		Usage:     "run the generation jobs listed in the " + configs.DefaultJobsFile,
+		ArgsUsage: "[job names]; all jobs are run by default",
+		Flags: []cli.Flag{
+			jobsFileFlag,
+			parallelIntFlag,
+			checkBoolFlag,
+			noCacheBoolFlag,
+		},
+		Action: runAction,
+	}
+}
+
+func runAction(c *cli.Context) error {
+	jobs, err := loadJobs(c)
+	if err != nil {
+		return cli.NewExitError("ERROR: "+err.Error(), 1)
+	}
+
+	results := runJobs(jobs, parallelOf(c), extraArgsOf(c))
+	if failed := reportJobs(jobs, results); failed > 0 {
+		return cli.NewExitError(fmt.Sprintf("ERROR: %d of %d jobs failed", failed, len(jobs)), 1)
+	}
+	return nil
+}
+
+// loadJobs reads the jobs file and selects the jobs listed in the arguments.
+// Working directory is changed to the directory of jobs file.
+func loadJobs(c *cli.Context) ([]configs.Job, error) {
+	path := c.String(fileFlag)
+	file, err := configs.ReadJobsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := selectJobs(file.Jobs, c.Args())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chdir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func parallelOf(c *cli.Context) int {
+	if parallel := c.Int(parallelFlag); parallel > 0 {
+		return parallel
+	}
+	return 1
+}
+
+// extraArgsOf returns the flags of the run, which are passed to all jobs.
+func extraArgsOf(c *cli.Context) []string {
+	var extraArgs []string
+	for _, name := range []string{checkFlag, noCacheFlag} {
+		if c.Bool(name) {
+			extraArgs = append(extraArgs, "--"+name)
+		}
+	}
+	return extraArgs
+}
+
+// reportJobs prints the results of the jobs and returns the number of failed ones.
+func reportJobs(jobs []configs.Job, results []jobResult) int {
+	var failed int
+	for i, job := range jobs {
+		status := "OK"
+		if results[i].err != nil {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("%-4s %s (%s)\n", status, job.Name, results[i].duration.Round(time.Millisecond))
+	}
+	return failed
+}
+
+// selectJobs returns the jobs with the given names or all jobs, if names are not passed.
+func selectJobs(jobs []configs.Job, names []string) ([]configs.Job, error) {
+	if len(names) == 0 {
+		return jobs, nil
+	}
+
+	byName := map[string]configs.Job{}
+	for _, job := range jobs {
+		byName[job.Name] = job
+	}
+
+	var selected []configs.Job
+	for _, name := range names {
+		job, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("job %q is not found", name)
+		}
+		selected = append(selected, job)
+	}
+	return selected, nil
+}
+
+type jobResult struct {
+	err      error
+	duration time.Duration
+}
+
+// runJobs runs the jobs in parallel. Jobs working with the same directories
+// are dependent, they are run one by one in the order of declaration.
+// Extra arguments are passed to all jobs.
+func runJobs(jobs []configs.Job, parallel int, extraArgs []string) []jobResult {
+	results := make([]jobResult, len(jobs))
+	done := make([]chan struct{}, len(jobs))
+	dirs := make([][]string, len(jobs))
+	for i, job := range jobs {
+		done[i] = make(chan struct{})
+		dirs[i] = jobDirs(job)
+	}
+
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job configs.Job) {
+			defer wg.Done()
+			defer close(done[i])
+
+			for j := 0; j < i; j++ {
+				if overlap(dirs[i], dirs[j]) {
+					<-done[j]
+				}
+			}
+
+			slots <- struct{}{}
+			start := time.Now()
+			err := runJob(job, extraArgs)
+			results[i] = jobResult{err: err, duration: time.Since(start)}
+			<-slots
+
+			if err != nil {
+				log.Printf("[ERROR] job %q failed: %v\n", job.Name, err)
+			}
+		}(i, job)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// jobRunner executes the job by the generator command.
+type jobRunner struct {
+	command func() cli.Command
+	// run generates the code with the options parsed by the command flags,
+	// header is a command written into the generated files.
+	run func(c *cli.Context, header string) error
+}
+
+var jobRunners = map[string]jobRunner{
+	configs.JobEnum: {
+		command: EnumCmd,
+		run: func(c *cli.Context, header string) error {
+			config := enumsConfig(c)
+			config.Command = header
+			if err := config.Validate(); err != nil {
+				return err
+			}
+			return generate.Enums(config)
+		},
+	},
+	configs.JobModel: {
+		command: ModelCmd,
+		run: func(c *cli.Context, header string) error {
+			config := modelConfig(c)
+			config.Command = header
+			if err := config.Validate(); err != nil {
+				return err
+			}
+			return generate.Model(config)
+		},
+	},
+	configs.JobBindata: {
+		command: BindataCmd,
+		run: func(c *cli.Context, _ string) error {
+			config := bindataConfig(c)
+			if err := config.Validate(); err != nil {
+				return err
+			}
+			return generate.Bindata(config)
+		},
+	},
+}
+
+// runJob parses the options of the job by the flags of generator command and runs it.
+// Extra arguments (like `--check`) are not written into the headers of generated files.
+func runJob(job configs.Job, extraArgs []string) error {
+	runner := jobRunners[job.Kind]
+	command := runner.command()
+
+	args, err := jobArgs(job, command.Flags)
+	if err != nil {
+		return err
+	}
+	header := job.Kind + " " + strings.Join(args, " ")
+	args = append(append([]string{}, extraArgs...), args...)
+
+	c, err := parseFlags(job.Kind, command.Flags, args)
+	if err != nil {
+		return err
+	}
+	return runner.run(c, header)
+}
+
+// parseFlags parses the arguments by the flags of the command.
+func parseFlags(name string, flags []cli.Flag, args []string) (*cli.Context, error) {
+	set := flag.NewFlagSet(name, flag.ContinueOnError)
+	set.SetOutput(ioutil.Discard)
+	for _, f := range flags {
+		f.Apply(set)
+	}
+	if err := set.Parse(args); err != nil {
+		return nil, fmt.Errorf("invalid options: %v", err)
+	}
+	return cli.NewContext(nil, set, nil), nil
+}
+
+// jobArgs converts the job into the command line arguments of the generator command.
+func jobArgs(job configs.Job, flags []cli.Flag) ([]string, error) {
+	byName := map[string]cli.Flag{}
+	for _, f := range flags {
+		byName[f.GetName()] = f
+	}
+
+	var args []string
+	if len(job.Types) > 0 {
+		args = append(args, "--"+typesFlag+"="+strings.Join(job.Types, ","))
+	}
+
+	var names []string
+	for name := range job.Options {
+		names = append(names, name)
+	}
+	// sort to keep arguments stable between invocations
+	sort.Strings(names)
+
+	for _, name := range names {
+		f, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown option %s", name)
+		}
+
+		switch value := job.Options[name].(type) {
+		case nil:
+			return nil, fmt.Errorf("option %s: value should be set", name)
+		case []interface{}:
+			var values []string
+			for _, v := range value {
+				values = append(values, fmt.Sprint(v))
+			}
+			if _, ok := f.(cli.StringSliceFlag); ok {
+				for _, v := range values {
+					args = append(args, "--"+name+"="+v)
+				}
+				continue
+			}
+			args = append(args, "--"+name+"="+strings.Join(values, ","))
+		default:
+			args = append(args, "--"+name+"="+fmt.Sprint(value))
+		}
+	}
+
+	if job.Kind == configs.JobBindata {
+		if _, ok := job.Options[outputFlag]; !ok {
+			args = append(args, "--"+outputFlag+"="+filepath.Join(job.Package, "bindata.go"))
+		}
+		return args, nil
+	}
+	return append(args, job.Package), nil
+}
+
+// jobDirs returns the directories, which the job reads or writes.
+func jobDirs(job configs.Job) []string {
+	pkg := strings.TrimSuffix(filepath.ToSlash(job.Package), "...")
+	dirs := []string{pkg}
+	if dir, ok := job.Options[dirFlag].(string); ok {
+		dirs = append(dirs, filepath.Join(pkg, dir))
+	}
+	if output, ok := job.Options[outputFlag].(string); ok {
+		dirs = append(dirs, filepath.Dir(output))
+	}
+
+	for i, dir := range dirs {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dirs[i] = abs
+		}
+	}
+	return dirs
+}
+
+// overlap checks if some directory of one list is the same
+// or a parent of some directory of another list.
+func overlap(dirs, others []string) bool {
+	for _, dir := range dirs {
+		for _, other := range others {
+			if dir == other || strings.HasPrefix(other, dir+string(filepath.Separator)) ||
+				strings.HasPrefix(dir, other+string(filepath.Separator)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+const (
+	intervalFlag = "interval"
+	debounceFlag = "debounce"
+)
+
+func WatchCmd() cli.Command {
+	return cli.Command{
+		Name:      "watch",
+		Usage:     "watch the sources of the jobs listed in the " + configs.DefaultJobsFile + " and rerun the affected ones",
+		ArgsUsage: "[job names]; all jobs are watched by default",
+		Flags: []cli.Flag{
+			jobsFileFlag,
+			parallelIntFlag,
+			cli.DurationFlag{
+				Name:  intervalFlag,
+				Usage: "how often the files are polled;",
+				Value: 500 * time.Millisecond,
+			},
+			cli.DurationFlag{
+				Name:  debounceFlag,
+				Usage: "jobs are rerun only when files stop changing for this time;",
+				Value: 300 * time.Millisecond,
+			},
+			noCacheBoolFlag,
+		},
+		Action: watchAction,
+	}
+}
+
+func watchAction(c *cli.Context) error {
+	jobs, err := loadJobs(c)
+	if err != nil {
+		return cli.NewExitError("ERROR: "+err.Error(), 1)
+	}
+
+	interval, debounce := c.Duration(intervalFlag), c.Duration(debounceFlag)
+	if interval <= 0 || debounce < 0 {
+		return cli.NewExitError("ERROR: interval and debounce should be positive", 1)
+	}
+
+	parallel, extraArgs := parallelOf(c), extraArgsOf(c)
+	snapshots := snapshotJobs(jobs)
+	log.Printf("[INFO] watching %d jobs, press Ctrl+C to stop\n", len(jobs))
+
+	for {
+		time.Sleep(interval)
+
+		current := snapshotJobs(jobs)
+		affected := changedJobs(snapshots, current)
+		if len(affected) == 0 {
+			continue
+		}
+
+		// wait until the files stop changing
+		for {
+			time.Sleep(debounce)
+			next := snapshotJobs(jobs)
+			changed := changedJobs(current, next)
+			if len(changed) == 0 {
+				break
+			}
+			for i := range changed {
+				affected[i] = true
+			}
+			current = next
+		}
+
+		var toRun []configs.Job
+		for i, job := range jobs {
+			if affected[i] {
+				toRun = append(toRun, job)
+			}
+		}
+
+		log.Printf("[INFO] files changed, rerun %s\n", jobNames(toRun))
+		results := runJobs(toRun, parallel, extraArgs)
+		reportJobs(toRun, results)
+
+		// files changed during the run are detected on the next poll,
+		// outputs of the jobs cause one more run, which is skipped by the cache
+		snapshots = current
+	}
+}
+
+func jobNames(jobs []configs.Job) string {
+	names := make([]string, len(jobs))
+	for i, job := range jobs {
+		names[i] = fmt.Sprintf("%q", job.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// snapshot is a state of the files watched by the job.
+type snapshot map[string]fileStamp
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func snapshotJobs(jobs []configs.Job) []snapshot {
+	snapshots := make([]snapshot, len(jobs))
+	for i, job := range jobs {
+		snapshots[i] = snapshotOf(job)
+	}
+	return snapshots
+}
+
+// changedJobs returns the indexes of the jobs, which files are different in the snapshots.
+func changedJobs(prev, next []snapshot) map[int]bool {
+	changed := map[int]bool{}
+	for i := range next {
+		if len(prev[i]) != len(next[i]) {
+			changed[i] = true
+			continue
+		}
+		for path, stamp := range next[i] {
+			if prevStamp, ok := prev[i][path]; !ok || !prevStamp.modTime.Equal(stamp.modTime) ||
+				prevStamp.size != stamp.size {
+				changed[i] = true
+				break
+			}
+		}
+	}
+	return changed
+}
+
+// snapshotOf returns the state of files, which are the sources of the job:
+// Go files of the packages and model template with partials or the bindata inputs.
+// Files that can't be read are skipped, they may appear later.
+func snapshotOf(job configs.Job) snapshot {
+	files := snapshot{}
+	add := func(path string) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+
+	switch job.Kind {
+	case configs.JobEnum, configs.JobModel:
+		dirs, err := parser.Dirs(job.Package)
+		if err != nil {
+			return files
+		}
+		for _, dir := range dirs {
+			matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+			for _, path := range matches {
+				if !strings.HasSuffix(path, "_test.go") {
+					add(path)
+				}
+			}
+		}
+		config := configs.ModelConfig{}
+		switch value := job.Options[tmplFlag].(type) {
+		case string:
+			config.TPaths = append(config.TPaths, value)
+		case []interface{}:
+			for _, v := range value {
+				config.TPaths = append(config.TPaths, fmt.Sprint(v))
+			}
+		}
+		tmpls, _ := config.TemplatePaths()
+		for _, tmpl := range tmpls {
+			add(tmpl)
+			partials, _ := templates.Partials(tmpl)
+			for _, path := range partials {
+				add(path)
+			}
+		}
+
+	case configs.JobBindata:
+		var inputs []string
+		switch value := job.Options[inputFlag].(type) {
+		case string:
+			inputs = append(inputs, value)
+		case []interface{}:
+			for _, v := range value {
+				inputs = append(inputs, fmt.Sprint(v))
+			}
+		}
+
+		for _, input := range inputs {
+			config := parseInput(input)
+			filepath.Walk(config.Path, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return nil
+				}
+				if info.IsDir() && path != config.Path && !config.Recursive {
+					return filepath.SkipDir
+				}
+				add(path)
+				return nil
+			})
+		}
+	}
+	return files
+}
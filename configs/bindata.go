@@ -138,6 +138,13 @@ type BindataConfig struct {
 	//
 	// This parameter can be provided multiple times.
 	Ignore []*regexp.Regexp
+
+	// Check enables the check mode: output is compared
+	// with the file on disk instead of writing.
+	Check bool
+
+	// NoCache disables the generation cache.
+	NoCache bool
 }
 
 // Asset holds information about a single asset to be processed.
@@ -179,6 +186,10 @@ func (c *BindataConfig) Validate() error {
 		if !os.IsNotExist(err) {
 			return fmt.Errorf("Output path: %v", err)
 		}
+		if c.Check {
+			// nothing is written in the check mode
+			return nil
+		}
 
 		// File does not exist. This is fine, just make
 		// sure the directory it is to be in exists.
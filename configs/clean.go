@@ -0,0 +1,9 @@
+package configs
+
+// CleanConfig is a config of the stale generated files removal.
+type CleanConfig struct {
+	// Dir is a directory of the package or the recursive pattern like `./...`.
+	Dir string
+	// Check enables the check mode: stale files are reported instead of removing.
+	Check bool
+}
@@ -1,23 +1,179 @@
 package configs
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lancer-kit/forge/parser"
 	"github.com/lancer-kit/forge/templates"
 )
 
 type EnumsConfig struct {
 	BaseConfig
+	EnumOptions
+
+	// Overrides holds the options of the particular types,
+	// which are used instead of the common EnumOptions.
+	Overrides map[string]EnumOptions
+
+	// BSONBuildTag is a build tag of the files with BSON marshaling methods,
+	// it's required only if the bson output is requested.
+	BSONBuildTag string
+
+	// Clean enables the removal of the stale files generated
+	// for the types earlier, see generate.StaleFiles.
+	Clean bool
+}
+
+// EnumOptions is a set of options that can be set for each enum type.
+type EnumOptions struct {
 	TransformRule templates.TransformRule
 	AddTypePrefix bool
+	// Outputs is a list of the optional output groups
+	// (see templates.EnumOutputs) to be generated.
+	// Nil is templates.DefaultEnumOutputs, the empty list generates none of them.
+	Outputs []string
+	// DuplicatePolicy defines which of constants with the same value
+	// is used as string representation, others are accepted on parse only.
+	DuplicatePolicy parser.DuplicatePolicy
+	// Lookup defines how the generated code finds the string of value.
+	Lookup templates.LookupMode
+}
+
+// WithDefaults returns a copy of the config with the zero options
+// of all types set to the defaults of the CLI flags, see EnumOptions.WithDefaults.
+func (config EnumsConfig) WithDefaults() EnumsConfig {
+	config.EnumOptions = config.EnumOptions.WithDefaults()
+	if len(config.Overrides) > 0 {
+		overrides := make(map[string]EnumOptions, len(config.Overrides))
+		for typeName, opts := range config.Overrides {
+			overrides[typeName] = opts.WithDefaults()
+		}
+		config.Overrides = overrides
+	}
+	return config
 }
 
 // Validate is an implementation of Validatable interface from ozzo-validation.
+// The zero options are valid, they are replaced by the defaults, see WithDefaults.
 func (config *EnumsConfig) Validate() error {
-	if err := config.BaseConfig.Validate(); err != nil {
+	// without explicit types list,
+	// they will be found by the forge:enum directives.
+	if len(config.Types) == 0 {
+		if err := config.BaseConfig.validateOutput(); err != nil {
+			return err
+		}
+	} else if err := config.BaseConfig.Validate(); err != nil {
+		return err
+	}
+
+	if config.OutputDir != "" {
+		// Go doesn't allow to declare the methods outside the package of the type.
+		return fmt.Errorf("dir: enum methods can be generated only in the package of the type")
+	}
+
+	withDefaults := config.WithDefaults()
+	if err := withDefaults.EnumOptions.Validate(); err != nil {
+		return err
+	}
+	bson := hasString(withDefaults.Outputs, "bson")
+	for typeName, opts := range withDefaults.Overrides {
+		if err := opts.Validate(); err != nil {
+			return fmt.Errorf("%s: %s", typeName, err)
+		}
+		bson = bson || hasString(opts.Outputs, "bson")
+	}
+	if bson && config.BSONBuildTag == "" {
+		return fmt.Errorf("bson-tag: should not be empty")
+	}
+
+	return nil
+}
+
+func hasString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// OptionsOf returns the options to be used for the type.
+func (config EnumsConfig) OptionsOf(typeName string) EnumOptions {
+	if opts, ok := config.Overrides[typeName]; ok {
+		return opts
+	}
+	return config.EnumOptions
+}
+
+// WithDefaults returns a copy of the options with the zero ones
+// set to the defaults of the CLI flags: nil Outputs are templates.DefaultEnumOutputs.
+func (opts EnumOptions) WithDefaults() EnumOptions {
+	if opts.TransformRule == "" {
+		opts.TransformRule = templates.TransformRuleNone
+	}
+	if opts.Outputs == nil {
+		opts.Outputs = templates.DefaultEnumOutputs
+	}
+	if opts.DuplicatePolicy == "" {
+		opts.DuplicatePolicy = parser.DuplicateFirst
+	}
+	if opts.Lookup == "" {
+		opts.Lookup = templates.LookupMap
+	}
+	return opts
+}
+
+// Validate is an implementation of Validatable interface from ozzo-validation.
+func (opts EnumOptions) Validate() error {
+	if err := opts.TransformRule.Validate(); err != nil {
+		return err
+	}
+	if err := opts.DuplicatePolicy.Validate(); err != nil {
 		return err
 	}
-	if err := config.TransformRule.Validate(); err != nil {
+	if err := opts.Lookup.Validate(); err != nil {
 		return err
 	}
 
+	for _, name := range opts.Outputs {
+		if _, ok := templates.EnumOutputs[name]; !ok {
+			return fmt.Errorf("outputs: unknown output %q", name)
+		}
+	}
 	return nil
 }
+
+// WithDirective returns a copy of the options updated
+// by the options of the forge:enum directive:
+//
+//	//forge:enum transform=snake tprefix outputs=json,sql duplicates=last lookup=auto
+func (opts EnumOptions) WithDirective(options map[string]string) (EnumOptions, error) {
+	for key, value := range options {
+		switch key {
+		case "transform":
+			opts.TransformRule = templates.TransformRule(value)
+		case "tprefix":
+			tprefix, err := strconv.ParseBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("tprefix: %s", err)
+			}
+			opts.AddTypePrefix = tprefix
+		case "outputs":
+			opts.Outputs = []string{}
+			if value != "" {
+				opts.Outputs = strings.Split(value, ",")
+			}
+		case "duplicates":
+			opts.DuplicatePolicy = parser.DuplicatePolicy(value)
+		case "lookup":
+			opts.Lookup = templates.LookupMode(value)
+		default:
+			return opts, fmt.Errorf("unknown option %q", key)
+		}
+	}
+
+	return opts, opts.Validate()
+}
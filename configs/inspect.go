@@ -0,0 +1,15 @@
+package configs
+
+// InspectConfig is a config of the packages inspection.
+type InspectConfig struct {
+	// Dir is a directory of the package or the recursive pattern like `./...`.
+	Dir string
+	// EnumOptions are used for the enum types without forge:enum directive.
+	EnumOptions
+}
+
+// Validate is an implementation of Validatable interface from ozzo-validation.
+// The zero options are valid, they are replaced by the defaults, see EnumOptions.WithDefaults.
+func (config *InspectConfig) Validate() error {
+	return config.EnumOptions.WithDefaults().Validate()
+}
@@ -0,0 +1,123 @@
+package configs
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultJobsFile is a default name of the file with generation jobs.
+const DefaultJobsFile = "forge.yaml"
+
+// Kinds of the generation jobs.
+const (
+	JobEnum    = "enum"
+	JobModel   = "model"
+	JobBindata = "bindata"
+)
+
+// JobsFile is a declarative list of the generation jobs:
+//
+//	jobs:
+//	  - name: enums
+//	    kind: enum
+//	    package: ./...
+//	    options:
+//	      transform: snake
+//	      outputs: [json, sql]
+//	  - kind: model
+//	    package: ./models
+//	    types: [User]
+//	    options:
+//	      tmpl: ./templates/q.tmpl
+//	      suffix: _q
+type JobsFile struct {
+	Jobs []Job `yaml:"jobs"`
+}
+
+// Job is a single run of the generator.
+type Job struct {
+	// Name identifies the job in the report, default is `<kind> <package>`.
+	Name string `yaml:"name"`
+	// Kind is a generator to run: enum, model or bindata.
+	Kind string `yaml:"kind"`
+	// Package is a directory of the target package or the recursive pattern like `./...`,
+	// for bindata it is a directory of the output file. Default is ".".
+	Package string   `yaml:"package"`
+	Types   []string `yaml:"types"`
+	// Options are the flags of the generator command, like `transform` or `tmpl`.
+	Options map[string]interface{} `yaml:"options"`
+}
+
+// ReadJobsFile reads and validates the jobs from the YAML file.
+func ReadJobsFile(path string) (*JobsFile, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file JobsFile
+	if err := yaml.UnmarshalStrict(raw, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := file.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &file, nil
+}
+
+// Validate is an implementation of Validatable interface from ozzo-validation.
+// It also sets the default values of the jobs.
+func (file *JobsFile) Validate() error {
+	if len(file.Jobs) == 0 {
+		return fmt.Errorf("jobs: should not be empty")
+	}
+
+	names := map[string]bool{}
+	for i := range file.Jobs {
+		job := &file.Jobs[i]
+		if job.Package == "" {
+			job.Package = "."
+		}
+		if job.Name == "" {
+			job.Name = job.Kind + " " + job.Package
+		}
+
+		if err := job.Validate(); err != nil {
+			return fmt.Errorf("job %q: %v", job.Name, err)
+		}
+		if names[job.Name] {
+			return fmt.Errorf("job %q: name is duplicated", job.Name)
+		}
+		names[job.Name] = true
+	}
+	return nil
+}
+
+// Validate is an implementation of Validatable interface from ozzo-validation.
+func (job Job) Validate() error {
+	switch job.Kind {
+	case JobEnum:
+	case JobModel:
+		if len(job.Types) == 0 {
+			return fmt.Errorf("types: should not be empty")
+		}
+	case JobBindata:
+		if len(job.Types) != 0 {
+			return fmt.Errorf("types: can't be used with bindata")
+		}
+	default:
+		return fmt.Errorf("kind: should be one of enum, model or bindata")
+	}
+
+	for name := range job.Options {
+		switch name {
+		case "type":
+			return fmt.Errorf("options: type should be set by types")
+		case "check", "no-cache":
+			return fmt.Errorf("options: %s can be set only for the whole run", name)
+		}
+	}
+	return nil
+}
@@ -8,12 +8,27 @@ import (
 )
 
 type BaseConfig struct {
-	Types        []string
-	MergeSpecs   bool
-	OutputDir    string
+	// Dir is the directory of the target package or
+	// the recursive pattern like `./...`. Default is ".".
+	Dir        string
+	Types      []string
+	MergeSpecs bool
+	// OutputDir is the directory of the output files,
+	// relative paths are resolved from the package directory.
+	OutputDir string
+	// OutputName is the explicit name of the output file,
+	// it replaces the name built from the type name, prefix and suffix.
 	OutputName   string
 	OutputSuffix string
 	OutputPrefix string
+	// Check enables the check mode: output is compared
+	// with the files on disk instead of writing.
+	Check bool
+	// NoCache disables the generation cache, see generate.CacheDir.
+	NoCache bool
+	// Command is the forge command written into the headers
+	// of the generated files, like `enum --type Color`.
+	Command string
 }
 
 // Validate is an implementation of Validatable interface from ozzo-validation.
@@ -21,6 +36,21 @@ func (config *BaseConfig) Validate() error {
 	if len(config.Types) == 0 {
 		return fmt.Errorf("type: should not be empty")
 	}
+
+	return config.validateOutput()
+}
+
+func (config *BaseConfig) validateOutput() error {
+	if config.OutputName != "" {
+		if len(config.Types) > 1 && !config.MergeSpecs {
+			return fmt.Errorf("name: can be used only with a single type or merge")
+		}
+		if strings.ContainsAny(config.OutputName, `/\`) {
+			return fmt.Errorf("name: should not contain the path, use dir instead")
+		}
+		return nil
+	}
+
 	if config.OutputPrefix == "" && config.OutputSuffix == "" {
 		return fmt.Errorf("sufix or prefix: should be passed")
 	}
@@ -29,6 +59,29 @@ func (config *BaseConfig) Validate() error {
 }
 
 func (config BaseConfig) GetPath(name, dir string) string {
+	return config.GetPathExt(name, dir, ".go")
+}
+
+// GetOutputDir returns the directory of output files
+// for the package placed in the dir.
+func (config BaseConfig) GetOutputDir(dir string) string {
+	if config.OutputDir == "" {
+		return dir
+	}
+	if filepath.IsAbs(config.OutputDir) {
+		return filepath.Clean(config.OutputDir)
+	}
+	return filepath.Join(dir, config.OutputDir)
+}
+
+// GetPathExt returns the path of the output file with the given extension.
+// The file is placed into the OutputDir and named by OutputName, if they are set.
+func (config BaseConfig) GetPathExt(name, dir, ext string) string {
+	dir = config.GetOutputDir(dir)
+	if config.OutputName != "" {
+		return filepath.Join(dir, strings.TrimSuffix(config.OutputName, ".go")+ext)
+	}
+
 	var splittedName string
 
 	for i, r := range name {
@@ -43,7 +96,7 @@ func (config BaseConfig) GetPath(name, dir string) string {
 		splittedName += string(r)
 	}
 
-	output := strings.ToLower(config.OutputPrefix + splittedName + config.OutputSuffix + ".go")
+	output := strings.ToLower(config.OutputPrefix + splittedName + config.OutputSuffix + ext)
 
 	return filepath.Join(dir, output)
 }
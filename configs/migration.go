@@ -0,0 +1,37 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultMigrationsDir is the directory of the migrations
+// in the scaffolded project relative to the models package.
+const DefaultMigrationsDir = "../dbschema/migrations"
+
+// MigrationConfig is a config of the SQL migrations of the struct types.
+// The OutputDir is the directory of migrations, the OutputName is the name
+// of the migration following the timestamp.
+type MigrationConfig struct {
+	BaseConfig
+	// Time is the time of the migration used in the file name, default is now.
+	Time time.Time
+}
+
+// Validate is an implementation of Validatable interface from ozzo-validation.
+func (config *MigrationConfig) Validate() error {
+	if len(config.Types) == 0 {
+		return fmt.Errorf("type: should not be empty")
+	}
+	if config.OutputName == "" {
+		return nil
+	}
+	if len(config.Types) > 1 {
+		return fmt.Errorf("name: can be used only with a single type")
+	}
+	if strings.ContainsAny(config.OutputName, `/\`) {
+		return fmt.Errorf("name: should not contain the path, use dir instead")
+	}
+	return nil
+}
@@ -2,29 +2,118 @@ package configs
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lancer-kit/forge/templates"
 )
 
 type ModelConfig struct {
 	BaseConfig
 	TPath string
+	// TPaths are more templates rendered with the TPath by one parse of the package.
+	// Each of TPath and TPaths is a template file or a directory of templates.
+	TPaths []string
+	// Flatten replaces the embedded struct fields
+	// by the promoted ones, see parser.StructureSpec.Flatten.
+	Flatten bool
 }
 
 // Validate is an implementation of Validatable interface from ozzo-validation.
 func (config *ModelConfig) Validate() error {
-	if err := config.BaseConfig.Validate(); err != nil {
-		return err
+	if len(config.Types) == 0 {
+		return fmt.Errorf("type: should not be empty")
 	}
-	if config.TPath == "" {
+	if config.TPath == "" && len(config.TPaths) == 0 {
 		return fmt.Errorf("tmpl: must be specified")
 	}
 
-	_, err := os.Stat(config.TPath)
-	if os.IsNotExist(err) {
-		return fmt.Errorf("tmpl: file is not exist")
-	}
+	paths, err := config.TemplatePaths()
 	if err != nil {
 		return fmt.Errorf("tmpl: %s", err.Error())
 	}
-	return nil
+	if len(paths) == 0 {
+		return fmt.Errorf("tmpl: no templates found")
+	}
+	// the accessor is always placed into the package of the type and calls New<Type>Q,
+	// it can't import the queries moved by the dir, as they import the type package
+	if config.OutputDir != "" && filepath.Clean(config.OutputDir) != "." &&
+		hasTemplate(paths, templates.BuiltinPrefix+"armory-q") && hasTemplate(paths, templates.BuiltinPrefix+"armory-q-accessor") {
+		return fmt.Errorf("dir: builtin:armory-q-accessor requires builtin:armory-q in the package of the type, generate them without dir")
+	}
+	// outputs can be set by the front matter of templates, it's checked on generation
+	if config.OutputName == "" && config.OutputPrefix == "" && config.OutputSuffix == "" {
+		return nil
+	}
+	return config.validateOutput()
+}
+
+func hasTemplate(paths []string, path string) bool {
+	for _, item := range paths {
+		if item == path {
+			return true
+		}
+	}
+	return false
+}
+
+// TemplatePaths returns the paths of the templates: the TPath and TPaths
+// with directories replaced by their *.tmpl files except the partials.
+// A template listed several times is returned once, built-in templates are kept as is.
+func (config *ModelConfig) TemplatePaths() ([]string, error) {
+	var list []string
+	if config.TPath != "" {
+		list = append(list, config.TPath)
+	}
+	list = append(list, config.TPaths...)
+
+	var paths []string
+	seen := map[string]bool{}
+	addPath := func(path string) {
+		if !seen[filepath.Clean(path)] {
+			seen[filepath.Clean(path)] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, path := range list {
+		if templates.IsBuiltin(path) {
+			if _, err := templates.ReadTemplate(path); err != nil {
+				return nil, err
+			}
+			addPath(path)
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file %s is not exist", path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			addPath(path)
+			continue
+		}
+
+		infos, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		var dirPaths []string
+		for _, info := range infos {
+			name := info.Name()
+			if !info.IsDir() && strings.HasSuffix(name, ".tmpl") && !strings.HasPrefix(name, "_") {
+				dirPaths = append(dirPaths, filepath.Join(path, name))
+			}
+		}
+		sort.Strings(dirPaths)
+		for _, path := range dirPaths {
+			addPath(path)
+		}
+	}
+	return paths, nil
 }
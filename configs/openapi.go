@@ -0,0 +1,34 @@
+package configs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultOpenAPIName is the name of the OpenAPI document of the package.
+const DefaultOpenAPIName = "openapi.yaml"
+
+// OpenAPIConfig is a config of the OpenAPI schemas of the struct types.
+// All structs of the package are used, if the Types are not listed.
+// The OutputName is the name of the document, default is DefaultOpenAPIName.
+type OpenAPIConfig struct {
+	BaseConfig
+}
+
+// Validate is an implementation of Validatable interface from ozzo-validation.
+func (config *OpenAPIConfig) Validate() error {
+	if strings.ContainsAny(config.OutputName, `/\`) {
+		return fmt.Errorf("name: should not contain the path, use dir instead")
+	}
+	return nil
+}
+
+// GetDocumentPath returns the path of the OpenAPI document for the package placed in the dir.
+func (config OpenAPIConfig) GetDocumentPath(dir string) string {
+	name := config.OutputName
+	if name == "" {
+		name = DefaultOpenAPIName
+	}
+	return filepath.Join(config.GetOutputDir(dir), name)
+}
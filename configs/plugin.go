@@ -0,0 +1,39 @@
+package configs
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+var pluginNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// PluginConfig is a config of the external generator run.
+type PluginConfig struct {
+	BaseConfig
+
+	// Name is a name of the plugin, the executable `forge-gen-<name>` is run.
+	Name string
+	// TransformRule, AddTypePrefix and DuplicatePolicy are applied to the values of enum types.
+	TransformRule   templates.TransformRule
+	AddTypePrefix   bool
+	DuplicatePolicy parser.DuplicatePolicy
+	// Options are passed to the plugin as is.
+	Options map[string]string
+}
+
+// Validate is an implementation of Validatable interface from ozzo-validation.
+func (config *PluginConfig) Validate() error {
+	if !pluginNameRe.MatchString(config.Name) {
+		return fmt.Errorf("name: should consist of letters, digits, '_' and '-'")
+	}
+	if len(config.Types) == 0 {
+		return fmt.Errorf("type: should not be empty")
+	}
+	if err := config.TransformRule.Validate(); err != nil {
+		return err
+	}
+	return config.DuplicatePolicy.Validate()
+}
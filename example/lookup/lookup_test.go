@@ -0,0 +1,145 @@
+package lookup
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestLookupModesAreEquivalent(t *testing.T) {
+	for i := SizeMapXS; i <= SizeMapXXL; i++ {
+		m, x, s := i, SizeIndex(i), SizeSwitch(i)
+
+		if m.String() != x.String() || x.String() != s.String() {
+			t.Errorf("String() mismatch for %d: %q, %q, %q", i, m, x, s)
+		}
+
+		mJSON, _ := m.MarshalJSON()
+		xJSON, xErr := x.MarshalJSON()
+		sJSON, sErr := s.MarshalJSON()
+		if xErr != nil || sErr != nil {
+			t.Errorf("MarshalJSON() failed for %d: %v, %v", i, xErr, sErr)
+		}
+		if string(mJSON) != string(xJSON) || string(xJSON) != string(sJSON) {
+			t.Errorf("MarshalJSON() mismatch for %d: %s, %s, %s", i, mJSON, xJSON, sJSON)
+		}
+
+		// the results are copies, changing them doesn't affect the next marshaling
+		xJSON[0], sJSON[0] = 'x', 'x'
+		if xNext, _ := x.MarshalJSON(); string(xNext) != string(mJSON) {
+			t.Errorf("MarshalJSON() of SizeIndex returned the shared slice for %d: %s", i, xNext)
+		}
+		if sNext, _ := s.MarshalJSON(); string(sNext) != string(mJSON) {
+			t.Errorf("MarshalJSON() of SizeSwitch returned the shared slice for %d: %s", i, sNext)
+		}
+	}
+
+	for _, i := range []int{-1, int(SizeMapXXL) + 1} {
+		if SizeMap(i).Validate() == nil || SizeIndex(i).Validate() == nil || SizeSwitch(i).Validate() == nil {
+			t.Errorf("Validate() must fail for %d", i)
+		}
+		if SizeIndex(i).String() != fmt.Sprintf("SizeIndex(%d)", i) ||
+			SizeSwitch(i).String() != fmt.Sprintf("SizeSwitch(%d)", i) {
+			t.Errorf("String() mismatch for invalid %d: %q, %q", i, SizeIndex(i), SizeSwitch(i))
+		}
+		if _, err := SizeIndex(i).MarshalJSON(); err == nil {
+			t.Errorf("MarshalJSON() must fail for %d", i)
+		}
+		if _, err := SizeSwitch(i).MarshalJSON(); err == nil {
+			t.Errorf("MarshalJSON() must fail for %d", i)
+		}
+	}
+}
+
+var (
+	sinkString string
+	sinkErr    error
+	sinkBytes  []byte
+)
+
+func BenchmarkString(b *testing.B) {
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkString = SizeMap(i % 6).String()
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkString = SizeIndex(i % 6).String()
+		}
+	})
+	b.Run("switch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkString = SizeSwitch(i % 6).String()
+		}
+	})
+}
+
+func BenchmarkValidate(b *testing.B) {
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkErr = SizeMap(i % 6).Validate()
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkErr = SizeIndex(i % 6).Validate()
+		}
+	})
+	b.Run("switch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkErr = SizeSwitch(i % 6).Validate()
+		}
+	})
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkBytes, sinkErr = SizeMap(i % 6).MarshalJSON()
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkBytes, sinkErr = SizeIndex(i % 6).MarshalJSON()
+		}
+	})
+	b.Run("switch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sinkBytes, sinkErr = SizeSwitch(i % 6).MarshalJSON()
+		}
+	})
+}
+
+func BenchmarkJSONMarshal(b *testing.B) {
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		v := struct{ Size []SizeMap }{Size: []SizeMap{SizeMapXS, SizeMapM, SizeMapXXL}}
+		for i := 0; i < b.N; i++ {
+			sinkBytes, sinkErr = json.Marshal(v)
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		b.ReportAllocs()
+		v := struct{ Size []SizeIndex }{Size: []SizeIndex{SizeIndexXS, SizeIndexM, SizeIndexXXL}}
+		for i := 0; i < b.N; i++ {
+			sinkBytes, sinkErr = json.Marshal(v)
+		}
+	})
+	b.Run("switch", func(b *testing.B) {
+		b.ReportAllocs()
+		v := struct{ Size []SizeSwitch }{Size: []SizeSwitch{SizeSwitchXS, SizeSwitchM, SizeSwitchXXL}}
+		for i := 0; i < b.N; i++ {
+			sinkBytes, sinkErr = json.Marshal(v)
+		}
+	})
+}
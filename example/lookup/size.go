@@ -0,0 +1,40 @@
+// Package lookup holds the same enum generated with the different
+// lookup modes, to compare their performance.
+package lookup
+
+//go:generate forge enum --type SizeMap --lookup map
+//go:generate forge enum --type SizeIndex --lookup index
+//go:generate forge enum --type SizeSwitch --lookup switch
+
+type SizeMap int
+
+const (
+	SizeMapXS SizeMap = iota
+	SizeMapS
+	SizeMapM
+	SizeMapL
+	SizeMapXL
+	SizeMapXXL
+)
+
+type SizeIndex int
+
+const (
+	SizeIndexXS SizeIndex = iota
+	SizeIndexS
+	SizeIndexM
+	SizeIndexL
+	SizeIndexXL
+	SizeIndexXXL
+)
+
+type SizeSwitch int
+
+const (
+	SizeSwitchXS SizeSwitch = iota
+	SizeSwitchS
+	SizeSwitchM
+	SizeSwitchL
+	SizeSwitchXL
+	SizeSwitchXXL
+)
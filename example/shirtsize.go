@@ -21,7 +21,7 @@ import (
 	"strings"
 )
 
-//go:generate forge enum --type ShirtSize,WeekDay --merge true
+//go:generate forge enum --type ShirtSize,WeekDay --merge
 
 //go:generate forge enum -type=ShirtSize
 
@@ -59,8 +59,10 @@
 //
 //	//go:generate forge -type=Pill
 //
-// If multiple constants have the same value, the lexically first matching name will
-// be used (in the example, Acetaminophen will print as "Paracetamol").
+// If multiple constants have the same value, the first declared name will
+// be used (in the example, Acetaminophen will print as "Paracetamol"),
+// but both names are accepted on parse. This can be changed with the
+// -duplicates flag: "last" prefers the last declared name, "error" rejects the type.
 //
 // With no arguments, it processes the package in the current directory.
 // Otherwise, the arguments must name a single directory holding a Go package
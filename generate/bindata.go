@@ -1,8 +1,9 @@
 package generate
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -18,6 +19,16 @@ import (
 // to Go code and writes new files to the output specified
 // in the given configuration.
 func Bindata(c *configs.BindataConfig) error {
+	files, err := GenerateBindata(c)
+	if err != nil {
+		return err
+	}
+	return files.Apply(c.Check)
+}
+
+// GenerateBindata converts the assets to Go code like Bindata,
+// but returns the output file instead of writing it.
+func GenerateBindata(c *configs.BindataConfig) (Files, error) {
 	var toc []configs.Asset
 
 	var knownFuncs = make(map[string]int)
@@ -26,27 +37,65 @@ func Bindata(c *configs.BindataConfig) error {
 	for _, input := range c.Input {
 		err := findFiles(input.Path, c.Prefix, input.Recursive, &toc, c.Ignore, knownFuncs, visitedPaths)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	// Create output file.
-	fd, err := os.Create(c.Output)
+	output, err := filepath.Abs(c.Output)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	key, err := bindataKey(c, output, toc)
+	if err != nil {
+		return nil, err
 	}
 
-	defer fd.Close()
+	return cached(c.NoCache, filepath.Dir(output), key, c.Output, func() (Files, error) {
+		var buf bytes.Buffer
+		if err := writeBindata(&buf, c, toc); err != nil {
+			return nil, err
+		}
+		return Files{c.Output: buf.Bytes()}, nil
+	})
+}
 
-	// Create a buffered writer for better performance.
-	bfd := bufio.NewWriter(fd)
-	defer bfd.Flush()
+// bindataKey returns the cache key of the assets: their content, metadata and options.
+func bindataKey(c *configs.BindataConfig, output string, toc []configs.Asset) (*cacheKey, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	options := *c
+	options.Check = false
+	// regexps are printed as pointers, so they are added separately
+	options.Ignore = nil
+	key := newCacheKey("bindata")
+	key.add(wd, output, fmt.Sprintf("%#v", options))
+	for _, re := range c.Ignore {
+		key.add(re.String())
+	}
+
+	for _, asset := range toc {
+		info, err := os.Stat(asset.Path)
+		if err != nil {
+			return nil, err
+		}
+		key.add(asset.Name, asset.Func, info.Mode().String(), info.ModTime().String())
+		if err := key.addFile(asset.Path); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
 
+// writeBindata writes the Go code of the assets.
+func writeBindata(bfd io.Writer, c *configs.BindataConfig, toc []configs.Asset) error {
 	// Write the header. This makes e.g. Github ignore diffs in generated files.
-	if _, err = fmt.Fprint(bfd, "// Code generated by forge bindata.\n"); err != nil {
+	if _, err := fmt.Fprint(bfd, "// Code generated by forge bindata.\n"); err != nil {
 		return err
 	}
-	if _, err = fmt.Fprint(bfd, "// sources:\n"); err != nil {
+	if _, err := fmt.Fprint(bfd, "// sources:\n"); err != nil {
 		return err
 	}
 
@@ -0,0 +1,246 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	goparser "go/parser"
+	"go/token"
+	"hash"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheDir is a directory of the generation cache,
+// it's placed in the root of the module.
+const CacheDir = ".forge/cache"
+
+// cacheVersion is a part of each cache key,
+// it must be changed with every change of the format of the cache entries.
+// The changes of the generators are tracked by the hash of forge executable.
+const cacheVersion = "2"
+
+// cacheTTL is a time after the last use of the cache entry,
+// when it's removed from the cache.
+const cacheTTL = 7 * 24 * time.Hour
+
+var (
+	buildOnce sync.Once
+	buildHash string
+)
+
+// forgeBuild returns the hash of the running forge executable, which
+// identifies the generators and their templates. It returns the empty string
+// if the executable can't be read, the cache is not used in this case.
+func forgeBuild() string {
+	buildOnce.Do(func() {
+		path, err := os.Executable()
+		if err != nil {
+			log.Printf("[WARN] cache is disabled: %v\n", err)
+			return
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			log.Printf("[WARN] cache is disabled: %v\n", err)
+			return
+		}
+		defer file.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, file); err != nil {
+			log.Printf("[WARN] cache is disabled: %v\n", err)
+			return
+		}
+		buildHash = hex.EncodeToString(h.Sum(nil))
+	})
+	return buildHash
+}
+
+// cacheKey is a content-addressed key of the generation: a hash of forge build,
+// the source files, their dependencies, templates and options.
+type cacheKey struct {
+	h hash.Hash
+	// deps are the import paths of the dependencies added to the key.
+	deps map[string]bool
+}
+
+func newCacheKey(kind string) *cacheKey {
+	key := &cacheKey{h: sha256.New(), deps: map[string]bool{}}
+	key.add(cacheVersion, forgeBuild(), kind)
+	return key
+}
+
+// add adds the strings to the key.
+func (key *cacheKey) add(parts ...string) {
+	for _, part := range parts {
+		fmt.Fprintf(key.h, "%d:%s;", len(part), part)
+	}
+}
+
+// addFile adds the path and the content of the file to the key.
+func (key *cacheKey) addFile(path string) error {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	key.add(path, string(src))
+	return nil
+}
+
+// addSources adds the Go files of the package placed in the dir,
+// except the test files and excluded ones (like outputs of the generation),
+// and the dependencies imported by them, see addDeps.
+func (key *cacheKey) addSources(dir string, exclude []string) error {
+	excluded := map[string]bool{}
+	for _, path := range exclude {
+		excluded[filepath.Clean(path)] = true
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+	// sort to keep key stable between invocations
+	sort.Strings(paths)
+
+	var imports []string
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if excluded[path] || strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		if err := key.addFile(path); err != nil {
+			return err
+		}
+
+		file, err := goparser.ParseFile(fset, path, nil, goparser.ImportsOnly)
+		if err != nil {
+			// the broken file fails the generation, the key doesn't matter
+			continue
+		}
+		for _, spec := range file.Imports {
+			if importPath, err := strconv.Unquote(spec.Path.Value); err == nil {
+				imports = append(imports, importPath)
+			}
+		}
+	}
+	return key.addDeps(dir, imports)
+}
+
+// addDeps adds the Go files of the imported packages and of their dependencies,
+// except the standard library. The packages are resolved by go/build from
+// the srcDir like by the parser, the unresolved ones are added by the import path only.
+func (key *cacheKey) addDeps(srcDir string, imports []string) error {
+	sort.Strings(imports)
+	for _, importPath := range imports {
+		if key.deps[importPath] || importPath == "C" || importPath == "unsafe" {
+			continue
+		}
+		key.deps[importPath] = true
+
+		pkg, err := build.Import(importPath, srcDir, 0)
+		if err != nil {
+			key.add(importPath)
+			continue
+		}
+		if pkg.Goroot {
+			continue
+		}
+
+		key.add(pkg.ImportPath)
+		for _, name := range append(pkg.GoFiles, pkg.CgoFiles...) {
+			if err := key.addFile(filepath.Join(pkg.Dir, name)); err != nil {
+				return err
+			}
+		}
+		if err := key.addDeps(pkg.Dir, pkg.Imports); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (key *cacheKey) String() string {
+	return hex.EncodeToString(key.h.Sum(nil))
+}
+
+// cacheRoot returns the root of the module containing the dir,
+// or the dir itself if it is not in the module.
+func cacheRoot(dir string) string {
+	for root := dir; ; root = filepath.Dir(root) {
+		if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+			return root
+		}
+		if filepath.Dir(root) == root {
+			return dir
+		}
+	}
+}
+
+// cached returns the files generated earlier for the same key or, if there are
+// no such files, generates and stores them. Broken cache entries are ignored,
+// the entries unused for cacheTTL are removed on store.
+func cached(noCache bool, dir string, key *cacheKey, what string, gen func() (Files, error)) (Files, error) {
+	if noCache || forgeBuild() == "" {
+		return gen()
+	}
+
+	cacheDir := filepath.Join(cacheRoot(dir), CacheDir)
+	path := filepath.Join(cacheDir, key.String()+".json")
+	if raw, err := ioutil.ReadFile(path); err == nil {
+		var files Files
+		if err := json.Unmarshal(raw, &files); err == nil {
+			log.Printf("[INFO] %s: unchanged, skipped\n", what)
+			// the time of the last use is kept by the modification time
+			now := time.Now()
+			_ = os.Chtimes(path, now, now)
+			return files, nil
+		}
+	}
+
+	files, err := gen()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(files)
+	if err != nil {
+		return nil, fmt.Errorf("caching: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("caching: %v", err)
+	}
+	if err := ioutil.WriteFile(path, raw, 0644); err != nil {
+		return nil, fmt.Errorf("caching: %v", err)
+	}
+	pruneCache(cacheDir, time.Now().Add(-cacheTTL))
+	return files, nil
+}
+
+// pruneCache removes the cache entries, which were not used since the time.
+// Failures are not critical for the generation, so they are only logged.
+func pruneCache(cacheDir string, since time.Time) {
+	infos, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		log.Printf("[WARN] pruning cache: %v\n", err)
+		return
+	}
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != ".json" || !info.ModTime().Before(since) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(cacheDir, info.Name())); err != nil && !os.IsNotExist(err) {
+			log.Printf("[WARN] pruning cache: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "a.go")
+	output := filepath.Join(dir, "a_enums.go")
+	require.NoError(t, ioutil.WriteFile(source, []byte("package a\n"), 0644))
+
+	var calls int
+	gen := func() (Files, error) {
+		calls++
+		return Files{output: []byte("package a\n"), filepath.Join(dir, "old.go"): nil}, nil
+	}
+	keyOf := func() *cacheKey {
+		key := newCacheKey("test")
+		require.NoError(t, key.addSources(dir, []string{output}))
+		return key
+	}
+
+	files, err := cached(false, dir, keyOf(), "a", gen)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	// outputs don't affect the key
+	require.NoError(t, ioutil.WriteFile(output, []byte("package a\n"), 0644))
+	cachedFiles, err := cached(false, dir, keyOf(), "a", gen)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, files, cachedFiles)
+
+	require.NoError(t, ioutil.WriteFile(source, []byte("package a\n\nconst A = 1\n"), 0644))
+	_, err = cached(false, dir, keyOf(), "a", gen)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	_, err = cached(true, dir, keyOf(), "a", gen)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCacheKey_addSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "dep"), 0755))
+	sources := map[string]string{
+		"go.mod":     "module example.com/a\n",
+		"a.go":       "package a\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/a/dep\"\n)\n\nvar _ = fmt.Sprint(dep.A)\n",
+		"dep/dep.go": "package dep\n\nconst A = 1\n",
+	}
+	for name, src := range sources {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644))
+	}
+
+	// the module packages are resolved from the working directory like by the parser
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	keyOf := func() string {
+		key := newCacheKey("test")
+		require.NoError(t, key.addSources(dir, nil))
+		return key.String()
+	}
+
+	key := keyOf()
+	assert.Equal(t, key, keyOf())
+
+	// the change of the dependency changes the key
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "dep", "dep.go"), []byte("package dep\n\nconst A = 2\n"), 0644))
+	assert.NotEqual(t, key, keyOf())
+}
+
+func Test_pruneCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	old, fresh := filepath.Join(dir, "old.json"), filepath.Join(dir, "fresh.json")
+	require.NoError(t, ioutil.WriteFile(old, []byte("{}"), 0644))
+	require.NoError(t, ioutil.WriteFile(fresh, []byte("{}"), 0644))
+	lastUse := time.Now().Add(-2 * cacheTTL)
+	require.NoError(t, os.Chtimes(old, lastUse, lastUse))
+
+	pruneCache(dir, time.Now().Add(-cacheTTL))
+	_, err = os.Stat(old)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(fresh)
+	assert.NoError(t, err)
+}
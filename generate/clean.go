@@ -0,0 +1,331 @@
+package generate
+
+import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/parser"
+)
+
+// Clean removes the stale files generated by forge enum
+// in the packages of the config, see StaleFiles.
+func Clean(config configs.CleanConfig) error {
+	files, err := StaleFiles(config)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		log.Println("[INFO] no stale files found")
+		return nil
+	}
+	return files.Apply(config.Check)
+}
+
+// StaleFiles returns the stale files generated by forge enum with nil content,
+// the Go files and the GraphQL schemas. Files are recognized by their headers, the file is stale when:
+//   - one of its types (receivers of the methods or types listed in the command
+//     of the header) is not declared in the package anymore;
+//   - one of its types is generated into the other file of the same output
+//     (like the Go code or BSON marshaling) as well, see duplicatedEnums.
+func StaleFiles(config configs.CleanConfig) (Files, error) {
+	dir := config.Dir
+	if dir == "" {
+		dir = "."
+	}
+	dirs, err := parser.Dirs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs ErrorList
+	files := Files{}
+	for _, dir := range dirs {
+		stale, err := staleEnums(dir, dir, nil, nil)
+		if err != nil {
+			errs.add(dir, "", "", err)
+			continue
+		}
+		files.Merge(stale)
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// enumHeaderPrefix starts the header of the files generated by forge enum.
+const enumHeaderPrefix = headerPrefix + "enum"
+
+// generatedEnum is a Go file or a GraphQL schema generated by forge enum.
+type generatedEnum struct {
+	Path string
+	// Types are the receivers of the methods declared in the file
+	// and the types listed in the command of its header.
+	Types []string
+	// Ext is the extension of the output, see enumExts.
+	Ext string
+}
+
+// staleEnums returns the stale enum files placed in the outDir with nil content.
+// If the types are set, the files are checked for the run of these types,
+// which generates the outputs: files of the same types, which are not
+// generated by the run, are stale as well.
+func staleEnums(dir, outDir string, types []string, outputs Files) (Files, error) {
+	generated, err := generatedEnums(outDir)
+	if err != nil || len(generated) == 0 {
+		return nil, err
+	}
+
+	exclude := make([]string, 0, len(generated))
+	for _, file := range generated {
+		exclude = append(exclude, file.Path)
+	}
+	declared, err := declaredTypes(dir, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	running := make(map[string]bool, len(types))
+	for _, typeName := range types {
+		running[typeName] = true
+	}
+
+	stale := Files{}
+	var kept []generatedEnum
+	for _, file := range generated {
+		if _, ok := outputs[file.Path]; ok {
+			continue
+		}
+		if reason := staleReason(file, declared, running); reason != "" {
+			log.Printf("[INFO] %s is stale: %s\n", file.Path, reason)
+			stale[file.Path] = nil
+			continue
+		}
+		kept = append(kept, file)
+	}
+
+	if len(types) == 0 {
+		for _, path := range duplicatedEnums(kept) {
+			stale[path] = nil
+		}
+	}
+	return stale, nil
+}
+
+// staleReason explains why the file is stale or returns empty string.
+func staleReason(file generatedEnum, declared, running map[string]bool) string {
+	for _, typeName := range file.Types {
+		if !declared[typeName] {
+			return fmt.Sprintf("type %s is not declared", typeName)
+		}
+	}
+	for _, typeName := range file.Types {
+		if running[typeName] {
+			return fmt.Sprintf("type %s is generated by the current run into the other file", typeName)
+		}
+	}
+	return ""
+}
+
+// duplicatedEnums returns the paths of files, which types are all generated into
+// other files of the same output. The files of fewer types (the separate ones rather
+// than the merged) own their types, the ties are resolved by the path. The merged file
+// with some types generated only by it is kept, since removing it drops their code,
+// the duplicates are reported to be resolved by hand. The methods declared by the removed
+// file are skipped by the kept one, so it should be regenerated after the removal.
+func duplicatedEnums(files []generatedEnum) []string {
+	sort.SliceStable(files, func(i, j int) bool {
+		if len(files[i].Types) != len(files[j].Types) {
+			return len(files[i].Types) < len(files[j].Types)
+		}
+		return files[i].Path < files[j].Path
+	})
+
+	owners := map[string]string{}
+	var result []string
+	for _, file := range files {
+		var duplicated []string
+		for _, typeName := range file.Types {
+			if owner, ok := owners[file.Ext+typeName]; ok {
+				duplicated = append(duplicated, fmt.Sprintf("%s into %s", typeName, owner))
+			}
+		}
+		switch {
+		case len(duplicated) > 0 && len(duplicated) == len(file.Types):
+			log.Printf("[INFO] %s is stale: its types are generated into other files (%s), regenerate them\n",
+				file.Path, strings.Join(duplicated, ", "))
+			result = append(result, file.Path)
+			continue
+		case len(duplicated) > 0:
+			log.Printf("[WARN] %s is kept with the code of its other types, but some are generated into other files (%s), resolve the duplicates by hand\n",
+				file.Path, strings.Join(duplicated, ", "))
+		}
+
+		for _, typeName := range file.Types {
+			if _, ok := owners[file.Ext+typeName]; !ok {
+				owners[file.Ext+typeName] = file.Path
+			}
+		}
+	}
+	return result
+}
+
+// generatedEnums returns the Go files and the GraphQL schemas in the dir generated by forge enum.
+func generatedEnums(dir string) ([]generatedEnum, error) {
+	paths, err := generatedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := generatedByPattern(dir, "*"+schemaExt)
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, schemas...)
+
+	var result []generatedEnum
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		header := headerOf(src)
+		if header != enumHeaderPrefix && !strings.HasPrefix(header, enumHeaderPrefix+" ") {
+			continue
+		}
+
+		file := generatedEnum{Path: path, Ext: enumExtOf(path)}
+		// the broken file is checked only by the types of the header
+		if file.Ext == schemaExt {
+			file.Types = schemaEnumsOf(src)
+		} else if parsed, err := goparser.ParseFile(token.NewFileSet(), path, src, 0); err == nil {
+			file.Types = receiversOf(parsed)
+		}
+		file.Types = appendUnique(file.Types, typesOfCommand(header)...)
+		result = append(result, file)
+	}
+	return result, nil
+}
+
+// enumExtOf returns the extension of the enum output of the file.
+func enumExtOf(path string) string {
+	switch {
+	case strings.HasSuffix(path, bsonExt):
+		return bsonExt
+	case strings.HasSuffix(path, schemaExt):
+		return schemaExt
+	}
+	return ".go"
+}
+
+// schemaEnumsOf returns the enum types defined by the GraphQL schema,
+// they are named like the Go types.
+func schemaEnumsOf(src []byte) []string {
+	var result []string
+	for _, line := range strings.Split(string(src), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "enum" {
+			result = appendUnique(result, fields[1])
+		}
+	}
+	return result
+}
+
+// typesOfCommand returns the types listed by the type flag of the forge command.
+func typesOfCommand(header string) []string {
+	args := strings.Fields(strings.TrimPrefix(header, headerPrefix))
+	for i, arg := range args {
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)
+		if !strings.HasPrefix(arg, "-") || name[0] != "type" {
+			continue
+		}
+		if len(name) == 2 {
+			return strings.Split(name[1], ",")
+		}
+		if i+1 < len(args) {
+			return strings.Split(args[i+1], ",")
+		}
+	}
+	return nil
+}
+
+// receiversOf returns the receiver types of the methods declared in the file.
+func receiversOf(file *ast.File) []string {
+	var result []string
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+			continue
+		}
+
+		expr := fn.Recv.List[0].Type
+		if star, ok := expr.(*ast.StarExpr); ok {
+			expr = star.X
+		}
+		if ident, ok := expr.(*ast.Ident); ok {
+			result = appendUnique(result, ident.Name)
+		}
+	}
+	return result
+}
+
+// declaredTypes returns the names of types declared in the Go files
+// of the dir except the excluded and test ones. Files aren't type-checked,
+// so the package may be broken, e.g. by the stale files.
+func declaredTypes(dir string, exclude []string) (map[string]bool, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool, len(exclude))
+	for _, path := range exclude {
+		excluded[path] = true
+	}
+
+	declared := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if excluded[path] || strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := goparser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				declared[spec.(*ast.TypeSpec).Name.Name] = true
+			}
+		}
+	}
+	return declared, nil
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, item := range list {
+			if item == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
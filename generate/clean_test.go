@@ -0,0 +1,94 @@
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleEnums(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sources := map[string]string{
+		"types.go": "package colors\n\ntype Color int\n\ntype Size int\n",
+		"enums_color.go": "// generated by forge enum --type Color; DO NOT EDIT\npackage colors\n\n" +
+			"func (r Color) String() string { return \"\" }\n",
+		"enums_kind.go": "// generated by forge enum --type Kind; DO NOT EDIT\npackage colors\n\n" +
+			"func (r Kind) String() string { return \"\" }\n",
+		"enums_a1b2c3d4.go": "// generated by forge enum --type Color,Size --merge; DO NOT EDIT\npackage colors\n",
+		"user.go":           "package colors\n\nfunc (r Size) String() string { return \"\" }\n",
+		// the types of the tests don't keep the files
+		"kind_test.go":        "package colors\n\ntype Kind int\n",
+		"enums_color.graphql": "# generated by forge enum --type Color --outputs gql; DO NOT EDIT\n\nenum Color {\n  RED\n}\n",
+		"enums_kind.graphql":  "# generated by forge enum --outputs gql; DO NOT EDIT\n\nenum Kind {\n  ONE\n}\n",
+		"schema.graphql":      "enum Kind {\n  ONE\n}\n",
+	}
+	for name, src := range sources {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644))
+	}
+
+	stale, err := staleEnums(dir, dir, []string{"Color", "Size"}, Files{
+		filepath.Join(dir, "enums_color.go"):      nil,
+		filepath.Join(dir, "enums_color.graphql"): nil,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Files{
+		filepath.Join(dir, "enums_kind.go"):      nil,
+		filepath.Join(dir, "enums_kind.graphql"): nil,
+		filepath.Join(dir, "enums_a1b2c3d4.go"):  nil,
+	}, stale)
+
+	stale, err = staleEnums(dir, dir, []string{"Size"}, Files{})
+	require.NoError(t, err)
+	assert.Equal(t, Files{
+		filepath.Join(dir, "enums_kind.go"):      nil,
+		filepath.Join(dir, "enums_kind.graphql"): nil,
+		filepath.Join(dir, "enums_a1b2c3d4.go"):  nil,
+	}, stale)
+}
+
+func TestStaleEnums_duplicates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sources := map[string]string{
+		"types.go": "package colors\n\ntype Color int\n\ntype Size int\n",
+		"enums_color.go": "// generated by forge enum --type Color --outputs json,bson; DO NOT EDIT\npackage colors\n\n" +
+			"func (r Color) String() string { return \"\" }\n",
+		"enums_color_bson.go": "// generated by forge enum --type Color --outputs json,bson; DO NOT EDIT\npackage colors\n\n" +
+			"func (r Color) MarshalBSONValue() (bsontype.Type, []byte, error) { return 0, nil, nil }\n",
+		"enums_a1b2c3d4.go": "// generated by forge enum --type Color,Size --merge; DO NOT EDIT\npackage colors\n\n" +
+			"func (r Color) String() string { return \"\" }\n",
+		"enums_size_bson.go": "// generated by forge enum --type Size --outputs bson; DO NOT EDIT\npackage colors\n\n" +
+			"func (r Size) MarshalBSONValue() (bsontype.Type, []byte, error) { return 0, nil, nil }\n",
+	}
+	for name, src := range sources {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644))
+	}
+
+	// the BSON companions are not duplicates of the Go code of the same types,
+	// the merged file is the only Go code of the Size, so it's kept
+	stale, err := staleEnums(dir, dir, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+
+	// the merged file is removed, when all its types have the separate files
+	src := "// generated by forge enum --type Size; DO NOT EDIT\npackage colors\n\nfunc (r Size) String() string { return \"\" }\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "enums_size.go"), []byte(src), 0644))
+	stale, err = staleEnums(dir, dir, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Files{filepath.Join(dir, "enums_a1b2c3d4.go"): nil}, stale)
+}
+
+func Test_typesOfCommand(t *testing.T) {
+	assert.Equal(t, []string{"A", "B"}, typesOfCommand("// generated by forge enum --type A,B --merge"))
+	assert.Equal(t, []string{"A"}, typesOfCommand("// generated by forge enum --merge -type=A"))
+	assert.Nil(t, typesOfCommand("// generated by forge enum ./..."))
+}
@@ -1,11 +1,12 @@
 package generate
 
 import (
-	"flag"
 	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
 	"hash/crc32"
-	"io/ioutil"
-	"os"
+	"log"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -16,75 +17,406 @@ import (
 	"github.com/lancer-kit/forge/templates"
 )
 
+// Enums generates the code for the enum types listed in the config and writes it.
+// If types are not listed, they are found by the forge:enum directives
+// in the packages matched by the config.Dir pattern.
 func Enums(config configs.EnumsConfig) error {
-	// Only one directory at a time can be processed, and the default is ".".
-	dir := "."
-	if args := flag.Args(); len(args) >= 1 {
-		dir = args[0]
+	files, err := GenerateEnums(config)
+	if err != nil {
+		return err
+	}
+	return files.Apply(config.Check)
+}
+
+// GenerateEnums generates the code for the enum types like Enums, but returns
+// the files instead of writing them. Previous outputs, which are not generated
+// anymore, are listed with nil content. The zero options are set to the defaults of the CLI.
+func GenerateEnums(config configs.EnumsConfig) (Files, error) {
+	if config.Dir == "" {
+		config.Dir = "."
+	}
+	config = config.WithDefaults()
+
+	if len(config.Types) == 0 {
+		return enumsByDirectives(config)
+	}
+
+	if parser.IsRecursive(config.Dir) {
+		return nil, fmt.Errorf("pattern %s can be used only with the forge:enum directives, without type list",
+			config.Dir)
+	}
+
+	dir, err := filepath.Abs(config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine absolute filepath for requested path %s: %v", config.Dir, err)
+	}
+
+	return enumsInDir(dir, config)
+}
+
+// GenerateEnumsFromFiles generates the code for the enum types declared in the
+// already parsed files of one package. If types are not listed, they are found
+// by the forge:enum directives, so files must be parsed with comments.
+// Output paths are relative to the config.Dir, the cache is never used.
+func GenerateEnumsFromFiles(fset *token.FileSet, files []*ast.File, config configs.EnumsConfig) (Files, error) {
+	config = config.WithDefaults()
+	if len(config.Types) == 0 {
+		directives := parser.DirectivesOf(files, parser.EnumDirective)
+		if len(directives) == 0 {
+			return nil, fmt.Errorf("no types marked with %s found", parser.EnumDirective)
+		}
+
+		var err error
+		config, err = withDirectives(config, directives)
+		if err != nil {
+			return nil, &Error{Dir: config.Dir, Err: err}
+		}
+	}
+
+	outputs := enumOutputs(config.Dir, config)
+	load := func(exclude []string) (*parser.Package, error) {
+		return parser.ParseFiles(fset, excludeParsed(fset, files, exclude))
 	}
-	dir, err := filepath.Abs(dir)
+	return renderEnums(config.Dir, config, outputs, load)
+}
+
+// enumsByDirectives generates the code for all types marked
+// with the forge:enum directive, the options of directive
+// override the common options passed by config.
+func enumsByDirectives(config configs.EnumsConfig) (Files, error) {
+	dirs, err := parser.Dirs(config.Dir)
 	if err != nil {
-		return fmt.Errorf("unable to determine absolute filepath for requested path %s: %v", dir, err)
+		return nil, err
+	}
+
+	// packages are generated independently, so errors of all of them are reported
+	var errs ErrorList
+	files := Files{}
+	for _, dir := range dirs {
+		directives, err := parser.FindDirectives(dir, parser.EnumDirective)
+		if err != nil {
+			errs.add(dir, "", "", err)
+			continue
+		}
+		if len(directives) == 0 {
+			continue
+		}
+
+		dirConfig, err := withDirectives(config, directives)
+		if err != nil {
+			errs.add(dir, "", "", err)
+			continue
+		}
+
+		log.Printf("[INFO] %s: found %s\n", dir, strings.Join(dirConfig.Types, ", "))
+		dirFiles, err := enumsInDir(dir, dirConfig)
+		if err != nil {
+			errs.add(dir, "", "", err)
+			continue
+		}
+		files.Merge(dirFiles)
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no types marked with %s found in %s", parser.EnumDirective, config.Dir)
+	}
+	return files, nil
+}
+
+// withDirectives returns the config for the types marked by the directives.
+func withDirectives(config configs.EnumsConfig, directives []parser.Directive) (configs.EnumsConfig, error) {
+	dirConfig := config
+	dirConfig.Types = nil
+	// all types of the package are written into one file, if its name is set
+	dirConfig.MergeSpecs = config.OutputName != ""
+	dirConfig.Overrides = map[string]configs.EnumOptions{}
+
+	for _, directive := range directives {
+		opts, err := config.EnumOptions.WithDirective(directive.Options)
+		if err != nil {
+			return config, fmt.Errorf("invalid directive of the type %s: %v", directive.TypeName, err)
+		}
+
+		dirConfig.Types = append(dirConfig.Types, directive.TypeName)
+		dirConfig.Overrides[directive.TypeName] = opts
+	}
+	return dirConfig, nil
+}
+
+// enumsInDir generates the code for the enum types of the package placed in the dir.
+// The result also lists previous outputs, which are not generated anymore, with nil content.
+func enumsInDir(dir string, config configs.EnumsConfig) (Files, error) {
+	files := enumOutputs(dir, config)
+
+	var stale []string
+	if config.Clean {
+		staleFiles, err := staleEnums(dir, config.GetOutputDir(dir), config.Types, files)
+		if err != nil {
+			return nil, &Error{Dir: dir, Err: fmt.Errorf("finding stale files: %v", err)}
+		}
+		// stale files are removed and, like the outputs, excluded from the parsing
+		files.Merge(staleFiles)
+		stale = staleFiles.Paths()
+	}
+
+	key := newCacheKey("enum")
+	options := config
+	options.Check = false
+	key.add(dir, templates.Fingerprint(), fmt.Sprintf("%#v", options))
+	key.add(stale...)
+	if err := key.addSources(dir, files.Paths()); err != nil {
+		return nil, &Error{Dir: dir, Err: fmt.Errorf("hashing sources: %v", err)}
 	}
 
+	what := dir + ": " + strings.Join(config.Types, ", ")
+	return cached(config.NoCache, dir, key, what, func() (Files, error) {
+		return renderEnums(dir, config, files, func(exclude []string) (*parser.Package, error) {
+			return parser.ParsePackage(dir, exclude...)
+		})
+	})
+}
+
+// enumOutputs returns all possible outputs for the enum types with nil content.
+// Already generated files for types are excluded from the parsing,
+// this is need for correct search of predefined by user type vars and methods.
+func enumOutputs(dir string, config configs.EnumsConfig) Files {
 	if len(config.Types) == 1 {
 		config.MergeSpecs = false
 	}
 
-	// need to remove already generated files for types
-	// this is need for correct search of predefined by user
-	// type vars and methods
+	files := Files{}
+	// outputs of the separate files are replaced by merged or named one
+	separate := config.BaseConfig
+	separate.OutputName = ""
 	for _, typeName := range config.Types {
-		// Remove safe because we already check is path valid
-		// and don't care about is present file - we need to remove it.
-		os.Remove(config.GetPath(typeName, dir))
+		for _, ext := range enumExts {
+			files[separate.GetPathExt(typeName, dir, ext)] = nil
+			files[config.GetPathExt(typeName, dir, ext)] = nil
+		}
 	}
 
 	if config.MergeSpecs {
-		os.Remove(config.GetPath(mergeTypeNames(config.Types), dir))
+		for _, ext := range enumExts {
+			files[config.GetPathExt(mergeTypeNames(config.Types), dir, ext)] = nil
+		}
 	}
+	return files
+}
 
-	pkg, err := parser.ParsePackage(dir)
+// renderEnums renders the code for the enum types into the files,
+// which already list the outputs to be excluded from the parsing.
+func renderEnums(dir string, config configs.EnumsConfig, files Files, load loadFunc) (Files, error) {
+	if len(config.Types) == 1 {
+		config.MergeSpecs = false
+	}
+
+	var errs ErrorList
+	pkg, err := load(files.Paths())
 	if err != nil {
-		return fmt.Errorf("parsing package: %v", err)
+		errs.add(dir, "", "parsing package", err)
+		return nil, errs.Err()
 	}
 
+	command := config.Command
+	if command == "" {
+		command = "enum --type " + strings.Join(config.Types, ",")
+	}
 	var analysis = templates.Analysis{
-		Command:     strings.Join(os.Args[1:], " "),
+		Command:     command,
 		PackageName: pkg.Name,
 		Types:       make(map[string]templates.TypeSpec),
 	}
 
-	rule := templates.TransformRule(config.TransformRule)
-
 	// Run generate for each type.
 	for _, typeName := range config.Types {
-		values, tmplsToExclude, err := pkg.ValuesOfType(typeName)
+		opts := config.OptionsOf(typeName)
+		values, tmplsToExclude, err := pkg.ValuesOfType(typeName, opts.DuplicatePolicy)
 		if err != nil {
-			return fmt.Errorf("finding values for type %v: %v", typeName, err)
+			errs.add(dir, typeName, "", err)
+			continue
+		}
+		layout := parser.LayoutOf(values)
+		reportLayout(typeName, layout)
+
+		excludeOutputs(tmplsToExclude, opts.Outputs)
+
+		typeValues := opts.TransformRule.TransformValues(typeName, values, opts.AddTypePrefix)
+		if err := checkStrings(values, typeValues, hasOutput(opts.Outputs, "gql")); err != nil {
+			errs.add(dir, typeName, "", err)
+			continue
 		}
+
 		analysis.Types[typeName] = templates.TypeSpec{
 			TypeName:    typeName,
-			Values:      rule.TransformValues(typeName, values, config.AddTypePrefix),
+			Values:      typeValues,
 			ExcludeList: tmplsToExclude,
+			GraphQL:     hasOutput(opts.Outputs, "gql"),
+			Lookup:      lookupOf(typeName, opts.Lookup, layout, tmplsToExclude),
+			Ordered:     orderedValues(values, typeValues),
+			Min:         layout.Min,
+		}
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	bsonTag := config.BSONBuildTag
+	if bsonTag == "" {
+		bsonTag = DefaultBSONBuildTag
+	}
+	outputs := []struct {
+		ext      string
+		generate func() (map[string][]byte, error)
+	}{
+		{".go", func() (map[string][]byte, error) { return analysis.GenerateByTemplate(config.MergeSpecs) }},
+		{bsonExt, func() (map[string][]byte, error) { return analysis.GenerateBSON(config.MergeSpecs, bsonTag) }},
+		{schemaExt, func() (map[string][]byte, error) { return analysis.GenerateSchema(config.MergeSpecs) }},
+	}
+	for _, output := range outputs {
+		sources, err := output.generate()
+		if err != nil {
+			return nil, &Error{Dir: dir, Err: err}
 		}
+		addEnumFiles(files, sources, dir, output.ext, config)
 	}
+	return files, nil
+}
 
-	for name, src := range analysis.GenerateByTemplate(config.MergeSpecs) {
+const (
+	// schemaExt is an extension of the GraphQL schema files.
+	schemaExt = ".graphql"
+	// bsonExt is an extension of the files with BSON marshaling methods.
+	bsonExt = "_bson.go"
+
+	// DefaultBSONBuildTag is a default build tag of the files with BSON marshaling methods.
+	DefaultBSONBuildTag = "bson"
+)
+
+// enumExts is a list of extensions of all files generated for enum.
+var enumExts = []string{".go", bsonExt, schemaExt}
+
+// addEnumFiles adds the generated sources to the files by their output paths.
+func addEnumFiles(files Files, sources map[string][]byte, dir, ext string, config configs.EnumsConfig) {
+	for name, src := range sources {
 		if config.MergeSpecs {
 			name = mergeTypeNames(config.Types)
 		}
+		files[config.GetPathExt(name, dir, ext)] = src
+	}
+}
 
-		if err := ioutil.WriteFile(config.GetPath(name, dir), src, 0644); err != nil {
-			return fmt.Errorf("writing output: %s", err)
+func hasOutput(outputs []string, name string) bool {
+	for _, output := range outputs {
+		if output == name {
+			return true
 		}
+	}
+	return false
+}
 
-		if config.MergeSpecs {
-			return nil
+// reportLayout warns about the negative values and gaps between values,
+// they are valid, but usually are the result of a mistake.
+func reportLayout(typeName string, layout parser.Layout) {
+	if len(layout.Negative) > 0 {
+		log.Printf("[WARN] type %s has negative values: %s\n", typeName, strings.Join(layout.Negative, ", "))
+	}
+	if !layout.Exact {
+		log.Printf("[WARN] type %s has values out of int64 range\n", typeName)
+		return
+	}
+	if missing := layout.Missing(); missing > 0 {
+		log.Printf("[WARN] type %s is sparse: %d values in range [%d, %d] are not defined\n",
+			typeName, missing, layout.Min, layout.Max)
+	}
+}
+
+// lookupOf resolves the lookup mode for the type. Index and switch lookups
+// are built from the generated names, so map is used if the user predefines
+// the ValueToName map or the String method.
+func lookupOf(typeName string, mode templates.LookupMode, layout parser.Layout,
+	excludeList map[string]bool) templates.LookupMode {
+	if mode == "" || mode == templates.LookupMap {
+		return templates.LookupMap
+	}
+
+	if excludeList["ValueToName"] || excludeList["String"] {
+		log.Printf("[WARN] type %s: %s lookup can't be used with predefined ValueToName or String, map is used\n",
+			typeName, mode)
+		return templates.LookupMap
+	}
+
+	switch mode {
+	case templates.LookupAuto:
+		if layout.Dense {
+			return templates.LookupIndex
+		}
+		return templates.LookupSwitch
+	case templates.LookupIndex:
+		if !layout.Dense {
+			log.Printf("[WARN] type %s: index lookup requires contiguous values, switch is used\n", typeName)
+			return templates.LookupSwitch
 		}
 	}
+	return mode
+}
 
-	return nil
+// orderedValues returns the canonical values sorted by value.
+func orderedValues(values []parser.Value, typeValues []templates.TypeValue) []templates.TypeValue {
+	order := make([]int, 0, len(values))
+	for i, v := range values {
+		if v.AliasOf == "" {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return constant.Compare(values[order[i]].Value, token.LSS, values[order[j]].Value)
+	})
+
+	result := make([]templates.TypeValue, len(order))
+	for i, index := range order {
+		result[i] = typeValues[index]
+	}
+	return result
+}
+
+// checkStrings verifies that string representations of the constants are unique,
+// otherwise they can't be parsed back to the value. GraphQL names are checked
+// only if the GraphQL output is generated.
+func checkStrings(values []parser.Value, typeValues []templates.TypeValue, gql bool) error {
+	var errs parser.ErrorList
+	names := map[string]string{}
+	gqlNames := map[string]string{}
+	for i, v := range typeValues {
+		if other, ok := names[v.Str]; ok {
+			errs.Add(values[i].Pos,
+				fmt.Sprintf("constants %s and %s have the same string representation %q", other, v.Name, v.Str))
+		}
+		if other, ok := gqlNames[v.GQL]; ok && gql {
+			errs.Add(values[i].Pos,
+				fmt.Sprintf("constants %s and %s have the same GraphQL name %q", other, v.Name, v.GQL))
+		}
+		names[v.Str] = v.Name
+		gqlNames[v.GQL] = v.Name
+	}
+	return errs.Err()
+}
+
+// excludeOutputs adds to the exclude list
+// templates of the output groups, which are not requested.
+func excludeOutputs(excludeList map[string]bool, outputs []string) {
+	for name, tmpls := range templates.EnumOutputs {
+		if hasOutput(outputs, name) {
+			continue
+		}
+		for _, tmpl := range tmpls {
+			excludeList[tmpl] = true
+		}
+	}
 }
 
 func mergeTypeNames(names []string) string {
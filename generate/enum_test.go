@@ -0,0 +1,117 @@
+package generate
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+const colorSrc = `package colors
+
+// Color is a color of the shirt.
+//forge:enum
+type Color int
+
+const (
+	ColorRed Color = iota
+	ColorGreen
+)
+`
+
+func TestGenerateEnumsFromFiles(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "color.go", colorSrc, goparser.ParseComments)
+	require.NoError(t, err)
+
+	config := configs.EnumsConfig{
+		BaseConfig: configs.BaseConfig{OutputSuffix: "_enums"},
+		EnumOptions: configs.EnumOptions{
+			TransformRule:   templates.TransformRuleSnake,
+			DuplicatePolicy: parser.DuplicateError,
+			Lookup:          templates.LookupMap,
+		},
+	}
+
+	cases := []struct {
+		name  string
+		types []string
+	}{
+		{name: "listed types", types: []string{"Color"}},
+		{name: "directives"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := config
+			config.Types = tc.types
+			files, err := GenerateEnumsFromFiles(fset, []*ast.File{file}, config)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"color_enums.go", "color_enums.graphql", "color_enums_bson.go"}, files.Paths())
+			assert.Nil(t, files["color_enums.graphql"])
+			assert.Contains(t, string(files["color_enums.go"]), "package colors")
+			assert.Contains(t, string(files["color_enums.go"]), `"green"`)
+		})
+	}
+
+	config.Types = []string{"Size"}
+	_, err = GenerateEnumsFromFiles(fset, []*ast.File{file}, config)
+	require.Error(t, err)
+	genErr, ok := err.(*Error)
+	require.True(t, ok)
+	assert.Equal(t, "Size", genErr.Type)
+}
+
+func TestGenerateEnumsFromFiles_defaults(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "color.go", colorSrc, goparser.ParseComments)
+	require.NoError(t, err)
+
+	// the zero options are the defaults of the CLI
+	config := configs.EnumsConfig{BaseConfig: configs.BaseConfig{Types: []string{"Color"}, OutputPrefix: "enums_"}}
+	require.NoError(t, config.Validate())
+	files, err := GenerateEnumsFromFiles(fset, []*ast.File{file}, config)
+	require.NoError(t, err)
+	src := string(files["enums_color.go"])
+	assert.Contains(t, src, "func (r Color) MarshalJSON() ([]byte, error) {")
+	assert.Contains(t, src, "func (r Color) Value() (driver.Value, error) {")
+
+	// the empty list generates none of the outputs
+	config.Outputs = []string{}
+	files, err = GenerateEnumsFromFiles(fset, []*ast.File{file}, config)
+	require.NoError(t, err)
+	src = string(files["enums_color.go"])
+	assert.Contains(t, src, "func (r Color) String() string {")
+	assert.NotContains(t, src, "func (r Color) MarshalJSON()")
+	assert.NotContains(t, src, "func (r Color) Value()")
+
+	// the build tag is required only for the bson output
+	config.Outputs = []string{"bson"}
+	assert.EqualError(t, config.Validate(), "bson-tag: should not be empty")
+	config.BSONBuildTag = DefaultBSONBuildTag
+	assert.NoError(t, config.Validate())
+}
+
+func Test_checkStrings(t *testing.T) {
+	values := []parser.Value{{Name: "ColorDarkRed"}, {Name: "ColorDarkred"}}
+	typeValues := []templates.TypeValue{
+		{Name: "ColorDarkRed", Str: "dark-red", GQL: "DARK_RED"},
+		{Name: "ColorDarkred", Str: "dark_red", GQL: "DARK_RED"},
+	}
+
+	// GraphQL names matter only for the GraphQL output
+	assert.NoError(t, checkStrings(values, typeValues, false))
+	assert.EqualError(t, checkStrings(values, typeValues, true),
+		`constants ColorDarkRed and ColorDarkred have the same GraphQL name "DARK_RED"`)
+
+	typeValues[1].Str = "dark-red"
+	assert.EqualError(t, checkStrings(values, typeValues, false),
+		`constants ColorDarkRed and ColorDarkred have the same string representation "dark-red"`)
+}
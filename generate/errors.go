@@ -0,0 +1,124 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+
+	"github.com/lancer-kit/forge/internal/errlist"
+	"github.com/lancer-kit/forge/parser"
+)
+
+// Error is a failure of the generation for the package or the particular type.
+type Error struct {
+	// Dir is a directory of the package, it may be empty for the parsed files.
+	Dir string
+	// Type is a name of the type, it is empty for the errors of the whole package.
+	Type string
+	// Pos is a position of the failure in the source or the template, it's invalid if unknown.
+	Pos token.Position
+	Err error
+}
+
+func (err *Error) Error() string {
+	msg := err.Err.Error()
+	if err.Type != "" {
+		msg = "type " + err.Type + ": " + msg
+	}
+	if err.Pos.IsValid() {
+		return err.Pos.String() + ": " + msg
+	}
+	if err.Dir != "" {
+		msg = err.Dir + ": " + msg
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// ErrorList is a list of the errors collected during the generation,
+// generation continues with other types after the failure of one.
+type ErrorList []*Error
+
+// add adds the error of the type generation to the list, the positioned errors
+// of the parser are added one by one with their positions. The context
+// (like "parsing package") is prepended to the messages, if it is set.
+func (list *ErrorList) add(dir, typeName, context string, err error) {
+	var errs parser.ErrorList
+	switch err := err.(type) {
+	case *Error:
+		*list = append(*list, err)
+		return
+	case ErrorList:
+		*list = append(*list, err...)
+		return
+	case *parser.Error, parser.ErrorList:
+		errs.AddError(err)
+	default:
+		if context != "" {
+			err = fmt.Errorf("%s: %v", context, err)
+		}
+		*list = append(*list, &Error{Dir: dir, Type: typeName, Err: err})
+		return
+	}
+
+	for _, e := range errs {
+		msg := e.Msg
+		if context != "" {
+			msg = context + ": " + msg
+		}
+		*list = append(*list, &Error{Dir: dir, Type: typeName, Pos: e.Pos, Err: errors.New(msg)})
+	}
+}
+
+// Err returns the list sorted by positions or nil, if the list is empty.
+func (list ErrorList) Err() error {
+	if len(list) == 0 {
+		return nil
+	}
+	if len(list) == 1 {
+		return list[0]
+	}
+	errlist.SortByPosition(list, func(i int) token.Position { return list[i].Pos })
+	return list
+}
+
+func (list ErrorList) Error() string {
+	return errlist.Join(len(list), func(i int) error { return list[i] })
+}
+
+// Diagnostic is an error in the form suitable
+// for the editors and CI annotations.
+type Diagnostic struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Dir     string `json:"dir,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message"`
+}
+
+// DiagnosticsOf returns the diagnostics of the error,
+// the result is empty if the error is nil.
+func DiagnosticsOf(err error) []Diagnostic {
+	var list ErrorList
+	if err != nil {
+		list.add("", "", "", err)
+	}
+
+	diagnostics := make([]Diagnostic, 0, len(list))
+	for _, e := range list {
+		diagnostics = append(diagnostics, Diagnostic{
+			File:    e.Pos.Filename,
+			Line:    e.Pos.Line,
+			Column:  e.Pos.Column,
+			Dir:     e.Dir,
+			Type:    e.Type,
+			Message: e.Err.Error(),
+		})
+	}
+	return diagnostics
+}
@@ -0,0 +1,244 @@
+package generate
+
+import (
+	"go/ast"
+	"go/build"
+	goparser "go/parser"
+	"go/token"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// fixImports adds the missing imports of the known packages, like the ones of the field
+// types, or of the standard library to the file and removes the unused imports like
+// goimports does. Unlike goimports, it doesn't search the packages in GOPATH,
+// so the result depends only on the file, its package placed in the srcDir,
+// the known packages, the standard library and the imported packages.
+// Other packages should be imported by the template.
+func fixImports(fset *token.FileSet, file *ast.File, path string, known []string) {
+	srcDir := filepath.Dir(path)
+
+	// the references to the packages are the selectors of the unresolved identifiers
+	refs := map[string]map[string]bool{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+			if refs[ident.Name] == nil {
+				refs[ident.Name] = map[string]bool{}
+			}
+			refs[ident.Name][sel.Sel.Name] = true
+		}
+		return true
+	})
+
+	imported := map[string]bool{}
+	for _, spec := range append([]*ast.ImportSpec(nil), file.Imports...) {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || importPath == "C" {
+			continue
+		}
+
+		var name string
+		if spec.Name != nil {
+			name = spec.Name.Name
+		} else {
+			name = packageName(importPath, srcDir)
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		if refs[name] == nil {
+			var alias string
+			if spec.Name != nil {
+				alias = spec.Name.Name
+			}
+			astutil.DeleteNamedImport(fset, file, alias, importPath)
+			continue
+		}
+		imported[name] = true
+	}
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		if !imported[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	// sort to keep the output stable between invocations
+	sort.Strings(names)
+
+	globals := packageGlobals(srcDir, file.Name.Name, path)
+	for _, name := range names {
+		if globals[name] {
+			continue
+		}
+		if importPath, ok := knownImport(name, known, srcDir); ok {
+			astutil.AddImport(fset, file, importPath)
+		} else if importPath, ok := stdlibImport(name, refs[name]); ok {
+			astutil.AddImport(fset, file, importPath)
+		}
+	}
+}
+
+// knownImport returns the first known import path of the package with the name.
+func knownImport(name string, known []string, srcDir string) (string, bool) {
+	for _, importPath := range known {
+		if packageName(importPath, srcDir) == name {
+			return importPath, true
+		}
+	}
+	return "", false
+}
+
+var packageNames sync.Map
+
+// packageName returns the name of the imported package or, if the package
+// can't be found, the last element of the import path except the major version.
+func packageName(importPath, srcDir string) string {
+	key := srcDir + "\x00" + importPath
+	if name, ok := packageNames.Load(key); ok {
+		return name.(string)
+	}
+
+	name := path.Base(importPath)
+	if pkg, err := build.Import(importPath, srcDir, 0); err == nil && pkg.Name != "" {
+		name = pkg.Name
+	} else if _, err := strconv.Atoi(strings.TrimPrefix(name, "v")); err == nil && strings.HasPrefix(name, "v") {
+		name = path.Base(path.Dir(importPath))
+	}
+	packageNames.Store(key, name)
+	return name
+}
+
+// packageGlobals returns the names declared in the package scope by the Go files
+// of the package placed in the dir, except the test files and the excluded one.
+// The package may be broken, so the broken files are skipped.
+func packageGlobals(dir, pkgName, exclude string) map[string]bool {
+	globals := map[string]bool{}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if path == exclude || strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := goparser.ParseFile(fset, path, nil, 0)
+		if err != nil || file.Name.Name != pkgName {
+			continue
+		}
+		for name := range file.Scope.Objects {
+			globals[name] = true
+		}
+	}
+	return globals
+}
+
+var (
+	stdlibOnce sync.Once
+	// stdlibByName are the import paths of the standard packages by the last elements of the paths.
+	stdlibByName map[string][]string
+)
+
+// stdlibImport returns the import path of the standard package with the name,
+// which exports all the symbols. The shortest path is chosen, if there are several.
+func stdlibImport(name string, symbols map[string]bool) (string, bool) {
+	stdlibOnce.Do(func() {
+		stdlibByName = map[string][]string{}
+		for _, importPath := range stdlibPaths() {
+			base := path.Base(importPath)
+			stdlibByName[base] = append(stdlibByName[base], importPath)
+		}
+		for _, paths := range stdlibByName {
+			sort.SliceStable(paths, func(i, j int) bool { return len(paths[i]) < len(paths[j]) })
+		}
+	})
+
+	for _, importPath := range stdlibByName[name] {
+		declared := packageExports(importPath, name)
+		if declared == nil {
+			continue
+		}
+		if exportsAll(declared, symbols) {
+			return importPath, true
+		}
+	}
+	return "", false
+}
+
+// stdlibPaths returns the import paths of the standard packages of the used Go version
+// listed by `go list std`, except the internal and vendored ones, which can't be imported.
+// If the go command fails, the standard packages aren't imported.
+func stdlibPaths() []string {
+	out, err := exec.Command("go", "list", "std").Output()
+	if err != nil {
+		return nil
+	}
+
+	var result []string
+	for _, importPath := range strings.Fields(string(out)) {
+		elems := "/" + importPath + "/"
+		if strings.Contains(elems, "/internal/") || strings.HasPrefix(importPath, "vendor/") {
+			continue
+		}
+		result = append(result, importPath)
+	}
+	return result
+}
+
+// exports are the names declared in the scopes of the standard packages by their import paths,
+// the packages are parsed once per run.
+var exports sync.Map
+
+// packageExports returns the names declared in the scope of the standard package
+// or nil, if the package can't be parsed or has another name.
+func packageExports(importPath, name string) map[string]bool {
+	if declared, ok := exports.Load(importPath); ok {
+		return declared.(map[string]bool)
+	}
+
+	var declared map[string]bool
+	if pkg, err := build.Default.Import(importPath, "", 0); err == nil && pkg.Name == name {
+		declared = parseExports(pkg)
+	}
+	exports.Store(importPath, declared)
+	return declared
+}
+
+// parseExports returns the names declared by the Go files of the package
+// or nil, if a file can't be parsed.
+func parseExports(pkg *build.Package) map[string]bool {
+	declared := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		file, err := goparser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, 0)
+		if err != nil {
+			return nil
+		}
+		for name := range file.Scope.Objects {
+			declared[name] = true
+		}
+	}
+	return declared
+}
+
+// exportsAll reports whether the package declares all the symbols.
+func exportsAll(declared, symbols map[string]bool) bool {
+	for symbol := range symbols {
+		if !declared[symbol] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package generate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_stdlibImport(t *testing.T) {
+	paths := stdlibPaths()
+	assert.Contains(t, paths, "net/http")
+	assert.Contains(t, paths, "math/rand")
+	for _, importPath := range paths {
+		assert.False(t, strings.Contains("/"+importPath+"/", "/internal/"), importPath)
+	}
+
+	importPath, ok := stdlibImport("rand", map[string]bool{"Intn": true})
+	assert.True(t, ok)
+	assert.Equal(t, "math/rand", importPath)
+	_, ok = stdlibImport("rand", map[string]bool{"Unknown": true})
+	assert.False(t, ok)
+
+	// the package is parsed once
+	declared := packageExports("math/rand", "rand")
+	assert.True(t, declared["Intn"])
+	assert.Equal(t, reflect.ValueOf(declared).Pointer(), reflect.ValueOf(packageExports("math/rand", "rand")).Pointer())
+}
@@ -0,0 +1,294 @@
+package generate
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+// Statuses of the enum templates.
+const (
+	// StatusGenerated means that the template is generated.
+	StatusGenerated = "generated"
+	// StatusDeclared means that the method or variable is declared
+	// in the package, so the template is skipped.
+	StatusDeclared = "declared"
+	// StatusConflict means that the method is declared with the other receiver,
+	// it isn't recognized, so the generated one breaks the package.
+	StatusConflict = "conflict"
+	// StatusDisabled means that the output group of the template isn't requested.
+	StatusDisabled = "disabled"
+)
+
+// Inspection lists the types of the package, which forge can generate the code for.
+type Inspection struct {
+	Dir     string `json:"dir"`
+	Package string `json:"package"`
+	// Generated are the files generated by forge earlier,
+	// they are excluded from the inspection.
+	Generated []string          `json:"generated,omitempty"`
+	Enums     []EnumCandidate   `json:"enums"`
+	Structs   []StructCandidate `json:"structs"`
+}
+
+// EnumCandidate is a type with the typed constants.
+type EnumCandidate struct {
+	Name string `json:"name"`
+	Pos  string `json:"pos"`
+	// Underlying is the underlying type, like int or string.
+	Underlying string `json:"underlying"`
+	// Directive is true if the type is marked with the forge:enum directive.
+	Directive bool        `json:"directive"`
+	Values    []EnumValue `json:"values,omitempty"`
+	// Error explains why the code for the type can't be generated.
+	Error     string           `json:"error,omitempty"`
+	Templates []TemplateStatus `json:"templates,omitempty"`
+}
+
+// EnumValue is a constant of the enum type.
+type EnumValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Str is a string representation of the value.
+	Str     string `json:"str"`
+	AliasOf string `json:"aliasOf,omitempty"`
+}
+
+// TemplateStatus tells if the enum template is generated, and why it's not.
+type TemplateStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// StructCandidate is a struct type, which can be used by the model templates.
+type StructCandidate struct {
+	Name    string        `json:"name"`
+	Pos     string        `json:"pos"`
+	Fields  []StructField `json:"fields"`
+	Methods []string      `json:"methods,omitempty"`
+	// Error explains why the model for the type can't be built.
+	Error string `json:"error,omitempty"`
+}
+
+// StructField is a field of the struct.
+type StructField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Tag  string `json:"tag,omitempty"`
+	// Embedded is true for the embedded fields, their promoted
+	// fields are passed to the model templates with the flatten option.
+	Embedded bool `json:"embedded,omitempty"`
+}
+
+// Inspect lists the enum and struct types of the packages matched by the config.Dir pattern.
+// The zero enum options are set to the defaults of the CLI.
+func Inspect(config configs.InspectConfig) ([]Inspection, error) {
+	if config.Dir == "" {
+		config.Dir = "."
+	}
+	config.EnumOptions = config.EnumOptions.WithDefaults()
+	dirs, err := parser.Dirs(config.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs ErrorList
+	var result []Inspection
+	for _, dir := range dirs {
+		inspection, err := inspectDir(dir, config)
+		if err != nil {
+			errs.add(dir, "", "", err)
+			continue
+		}
+		result = append(result, *inspection)
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func inspectDir(dir string, config configs.InspectConfig) (*Inspection, error) {
+	generated, err := generatedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs ErrorList
+	pkg, err := parser.ParsePackage(dir, generated...)
+	if err != nil {
+		errs.add(dir, "", "parsing package", err)
+		return nil, errs.Err()
+	}
+
+	directives, err := parser.FindDirectives(dir, parser.EnumDirective)
+	if err != nil {
+		return nil, err
+	}
+	overrides := map[string]configs.EnumOptions{}
+	for _, directive := range directives {
+		opts, err := config.EnumOptions.WithDirective(directive.Options)
+		if err != nil {
+			errs.add(dir, directive.TypeName, "invalid directive", err)
+			continue
+		}
+		overrides[directive.TypeName] = opts
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	inspection := &Inspection{Dir: dir, Package: pkg.Name}
+	for _, path := range generated {
+		inspection.Generated = append(inspection.Generated, filepath.Base(path))
+	}
+
+	for _, decl := range pkg.Types() {
+		switch underlying := decl.Underlying.(type) {
+		case *types.Struct:
+			inspection.Structs = append(inspection.Structs, inspectStruct(dir, pkg, decl, underlying))
+		case *types.Basic:
+			if underlying.Info()&(types.IsInteger|types.IsString) == 0 || len(decl.Constants) == 0 {
+				continue
+			}
+			opts, directive := overrides[decl.Name]
+			if !directive {
+				opts = config.EnumOptions
+			}
+			enum := inspectEnum(dir, pkg, decl, opts)
+			enum.Directive = directive
+			inspection.Enums = append(inspection.Enums, enum)
+		}
+	}
+	return inspection, nil
+}
+
+// inspectEnum checks the values of enum type and the statuses of its templates.
+func inspectEnum(dir string, pkg *parser.Package, decl parser.TypeDecl, opts configs.EnumOptions) EnumCandidate {
+	enum := EnumCandidate{
+		Name:       decl.Name,
+		Pos:        relPosition(dir, decl.Pos),
+		Underlying: decl.Underlying.String(),
+	}
+
+	values, declared, err := pkg.ValuesOfType(decl.Name, opts.DuplicatePolicy)
+	if err != nil {
+		enum.Error = err.Error()
+		return enum
+	}
+
+	typeValues := opts.TransformRule.TransformValues(decl.Name, values, opts.AddTypePrefix)
+	for i, value := range values {
+		enum.Values = append(enum.Values, EnumValue{
+			Name:    value.Name,
+			Value:   value.Value.ExactString(),
+			Str:     typeValues[i].Str,
+			AliasOf: value.AliasOf,
+		})
+	}
+	if err := checkStrings(values, typeValues, hasOutput(opts.Outputs, "gql")); err != nil {
+		enum.Error = err.Error()
+	}
+
+	methods := map[string]parser.Method{}
+	for _, method := range pkg.MethodsOfType(decl.Name) {
+		methods[method.Name] = method
+	}
+	disabled := map[string]bool{}
+	excludeOutputs(disabled, opts.Outputs)
+
+	for _, tmpl := range append(append([]templates.CodeTemplate{}, templates.EnumBase...), templates.EnumBSON...) {
+		status := TemplateStatus{Name: tmpl.Name, Status: StatusGenerated}
+		method, isMethod := methods[tmpl.Name]
+		switch {
+		case declared[tmpl.Name] && isMethod:
+			status.Status = StatusDeclared
+			status.Reason = "declared at " + relPosition(dir, method.Pos)
+		case declared[tmpl.Name]:
+			status.Status = StatusDeclared
+			status.Reason = "declared in the package"
+		case isMethod:
+			status.Status = StatusConflict
+			status.Reason = fmt.Sprintf("declared at %s with the %s receiver, it conflicts with the generated one",
+				relPosition(dir, method.Pos), receiverKind(method.Pointer))
+		case disabled[tmpl.Name]:
+			status.Status = StatusDisabled
+			status.Reason = fmt.Sprintf("output %s isn't requested", outputOf(tmpl.Name))
+		}
+		enum.Templates = append(enum.Templates, status)
+	}
+	return enum
+}
+
+// outputOf returns the name of the output group of the template.
+func outputOf(tmplName string) string {
+	for output, names := range templates.EnumOutputs {
+		for _, name := range names {
+			if name == tmplName {
+				return output
+			}
+		}
+	}
+	return ""
+}
+
+func receiverKind(pointer bool) string {
+	if pointer {
+		return "pointer"
+	}
+	return "value"
+}
+
+// inspectStruct lists the fields of the struct and tells which of them are passed to the model templates.
+func inspectStruct(dir string, pkg *parser.Package, decl parser.TypeDecl, st *types.Struct) StructCandidate {
+	result := StructCandidate{Name: decl.Name, Pos: relPosition(dir, decl.Pos)}
+	for _, method := range pkg.MethodsOfType(decl.Name) {
+		result.Methods = append(result.Methods, method.Name)
+	}
+
+	spec, err := pkg.FindStructureSpec(decl.Name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if _, err := templates.FigureOut(spec); err != nil {
+		result.Error = err.Error()
+	}
+
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		result.Fields = append(result.Fields, StructField{
+			Name:     field.Name(),
+			Type:     types.TypeString(field.Type(), relativeTo(field.Pkg())),
+			Tag:      st.Tag(i),
+			Embedded: field.Embedded(),
+		})
+	}
+	return result
+}
+
+// relativeTo returns the qualifier, which writes types as in the package:
+// its types aren't qualified, others are qualified by the package name.
+func relativeTo(pkg *types.Package) types.Qualifier {
+	return func(other *types.Package) string {
+		if other == pkg {
+			return ""
+		}
+		return other.Name()
+	}
+}
+
+// relPosition returns the position with the file name relative to the dir.
+func relPosition(dir string, pos token.Position) string {
+	if rel, err := filepath.Rel(dir, pos.Filename); err == nil {
+		pos.Filename = rel
+	}
+	return pos.String()
+}
@@ -0,0 +1,28 @@
+package generate
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+
+	"github.com/lancer-kit/forge/parser"
+)
+
+// loadFunc loads the package ignoring the excluded files.
+type loadFunc func(exclude []string) (*parser.Package, error)
+
+// excludeParsed returns the parsed files, which names are not listed in exclude.
+func excludeParsed(fset *token.FileSet, files []*ast.File, exclude []string) []*ast.File {
+	excluded := make(map[string]bool, len(exclude))
+	for _, path := range exclude {
+		excluded[filepath.Clean(path)] = true
+	}
+
+	var result []*ast.File
+	for _, file := range files {
+		if !excluded[filepath.Clean(fset.File(file.Pos()).Name())] {
+			result = append(result, file)
+		}
+	}
+	return result
+}
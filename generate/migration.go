@@ -0,0 +1,470 @@
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+// Migration generates the SQL migrations of the tables
+// of the types listed in the config and writes them.
+func Migration(config configs.MigrationConfig) error {
+	files, err := GenerateMigrations(config)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		log.Println("[INFO] schemas of the tables are not changed")
+		return nil
+	}
+	return files.Apply(config.Check)
+}
+
+// GenerateMigrations generates the migrations like Migration, but returns
+// the files instead of writing them. The table is created by the migration
+// or, if the previous migration generated by forge exists, it's altered by
+// the difference with the previous schema. Unchanged tables aren't migrated.
+func GenerateMigrations(config configs.MigrationConfig) (Files, error) {
+	// Only one directory at a time can be processed, and the default is ".".
+	dir := "."
+	if config.Dir != "" {
+		dir = config.Dir
+	}
+	outDir := config.GetOutputDir(dir)
+
+	stamp := config.Time
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	command := config.Command
+	if command == "" {
+		command = "migration --type " + strings.Join(config.Types, ",")
+	}
+
+	previous, err := previousSchemas(outDir)
+	if err != nil {
+		return nil, &Error{Dir: dir, Err: fmt.Errorf("reading migrations: %v", err)}
+	}
+
+	var errs ErrorList
+	pkg, err := parser.ParsePackage(dir)
+	if err != nil {
+		errs.add(dir, "", "parsing package", err)
+		return nil, errs.Err()
+	}
+
+	files := Files{}
+	for _, typeName := range config.Types {
+		spec, err := pkg.FindStructureSpec(typeName)
+		if err != nil {
+			errs.add(dir, typeName, "finding structure", err)
+			continue
+		}
+
+		// embedded structs are the part of the table, like sqlx maps them
+		model, err := templates.FigureOut(spec.Flatten())
+		if err != nil {
+			errs.add(dir, typeName, "", err)
+			continue
+		}
+		schema, err := tableSchemaOf(model)
+		if err != nil {
+			errs.add(dir, typeName, "", err)
+			continue
+		}
+
+		name := "create_" + schema.Name
+		up, down := createTable(schema), []string{fmt.Sprintf("DROP TABLE %s;", quoteIdent(schema.Name))}
+		if prev, ok := previous[schema.Name]; ok {
+			name = "alter_" + schema.Name
+			up, down = alterTable(prev.Schema, schema), alterTable(schema, prev.Schema)
+			if len(up) == 0 {
+				log.Printf("[INFO] table %s is not changed since %s\n", schema.Name, prev.Path)
+				continue
+			}
+		}
+		if config.OutputName != "" {
+			name = strings.TrimSuffix(config.OutputName, ".sql")
+		}
+
+		path := filepath.Join(outDir, stamp.UTC().Format(migrationTimeLayout)+"_"+name+".sql")
+		src, err := renderMigration(command, schema, up, down)
+		if err != nil {
+			errs.add(dir, typeName, "", err)
+			continue
+		}
+		files[path] = src
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// migrationTimeLayout is the layout of the timestamp starting the names of migrations,
+// sql-migrate orders migrations by it.
+const migrationTimeLayout = "20060102150405"
+
+// schemaPrefix starts the comment of the migration with the table schema,
+// the next migration of the table is the difference with it.
+const schemaPrefix = "-- forge:schema "
+
+// tableSchema is the schema of the table generated by the struct type.
+type tableSchema struct {
+	Name       string         `json:"table"`
+	Columns    []columnSchema `json:"columns"`
+	PrimaryKey []string       `json:"primaryKey,omitempty"`
+}
+
+type columnSchema struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable,omitempty"`
+	Unique   bool   `json:"unique,omitempty"`
+	Index    bool   `json:"index,omitempty"`
+}
+
+// column returns the column by the name.
+func (schema tableSchema) column(name string) (columnSchema, bool) {
+	for _, column := range schema.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return columnSchema{}, false
+}
+
+// tableSchemaOf returns the schema of the table of the model. Columns are the fields
+// with the `db` tag, the tag options mark the primary key (pk), unique columns (unique)
+// and indexed ones (index). The `type=` option replaces the type of the column.
+func tableSchemaOf(model *templates.ModelSpec) (tableSchema, error) {
+	schema := tableSchema{Name: templates.TableName(model.TypeName)}
+	for _, field := range model.Fields {
+		name := field.Tags["db"]
+		if name == "" {
+			continue
+		}
+		if _, ok := schema.column(name); ok {
+			return schema, fmt.Errorf("field %s: column %s is duplicated", field.Name, name)
+		}
+
+		options := field.TagOpts["db"]
+		column := columnSchema{Name: name, Unique: options["unique"], Index: options["index"]}
+		column.Type, column.Nullable = columnType(field)
+		for option := range options {
+			if strings.HasPrefix(option, "type=") {
+				column.Type = strings.TrimPrefix(option, "type=")
+			}
+		}
+		if options["pk"] {
+			schema.PrimaryKey = append(schema.PrimaryKey, name)
+			column.Nullable = false
+		}
+		schema.Columns = append(schema.Columns, column)
+	}
+
+	if len(schema.Columns) == 0 {
+		return schema, fmt.Errorf("table %s has no columns: fields should have the db tags", schema.Name)
+	}
+
+	// the single integer primary key is generated by the database
+	if len(schema.PrimaryKey) == 1 {
+		for i, column := range schema.Columns {
+			if serial, ok := serialTypes[column.Type]; ok && column.Name == schema.PrimaryKey[0] {
+				schema.Columns[i].Type = serial
+			}
+		}
+	}
+	return schema, nil
+}
+
+// serialTypes are the auto-incremented types of the integer ones.
+var serialTypes = map[string]string{
+	"smallint": "smallserial",
+	"integer":  "serial",
+	"bigint":   "bigserial",
+}
+
+// nullTypes are the column types of the database/sql nullable types.
+var nullTypes = map[string]string{
+	"sql.NullString":  "text",
+	"sql.NullInt64":   "bigint",
+	"sql.NullInt32":   "integer",
+	"sql.NullInt16":   "smallint",
+	"sql.NullByte":    "smallint",
+	"sql.NullFloat64": "double precision",
+	"sql.NullBool":    "boolean",
+	"sql.NullTime":    "timestamp with time zone",
+}
+
+// basicTypes are the column types of the Go basic types.
+var basicTypes = map[string]string{
+	"string":  "text",
+	"bool":    "boolean",
+	"int":     "bigint",
+	"int64":   "bigint",
+	"uint":    "bigint",
+	"uint64":  "bigint",
+	"uint32":  "bigint",
+	"int32":   "integer",
+	"uint16":  "integer",
+	"int16":   "smallint",
+	"int8":    "smallint",
+	"uint8":   "smallint",
+	"float32": "real",
+	"float64": "double precision",
+}
+
+// columnType returns the PostgreSQL type of the field and whether it's nullable:
+// pointers and database/sql nullable types are. Forge enums are stored by the names
+// of their values, other named types are stored by their basic types, slices, maps
+// and other structs are stored as jsonb.
+func columnType(field templates.Field) (string, bool) {
+	typ := strings.TrimPrefix(field.FType, "*")
+	if column, ok := nullTypes[typ]; ok {
+		return column, true
+	}
+
+	switch {
+	case typ == "time.Time":
+		return "timestamp with time zone", field.Pointer
+	case typ == "[]byte":
+		return "bytea", field.Pointer
+	case field.Enum:
+		return "text", field.Pointer
+	case field.Slice || field.Map:
+		return "jsonb", field.Pointer
+	}
+	if column, ok := basicTypes[field.Basic]; ok {
+		return column, field.Pointer
+	}
+	return "jsonb", field.Pointer
+}
+
+// migrationSchema is the schema of the table written into the migration.
+type migrationSchema struct {
+	Path   string
+	Schema tableSchema
+}
+
+// previousSchemas returns the latest schemas of the tables
+// written into the migrations of the dir by their names.
+func previousSchemas(dir string) (map[string]migrationSchema, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	result := map[string]migrationSchema{}
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(src))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, schemaPrefix) {
+				continue
+			}
+
+			var schema tableSchema
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, schemaPrefix)), &schema); err != nil {
+				return nil, fmt.Errorf("%s: invalid schema: %v", path, err)
+			}
+			result[schema.Name] = migrationSchema{Path: path, Schema: schema}
+		}
+	}
+	return result, nil
+}
+
+// renderMigration renders the sql-migrate migration with the schema of the table.
+func renderMigration(command string, schema tableSchema, up, down []string) ([]byte, error) {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "-- generated by forge %s\n", command)
+	fmt.Fprintf(&buf, "%s%s\n\n", schemaPrefix, raw)
+	fmt.Fprintf(&buf, "-- +migrate Up\n%s\n\n", strings.Join(up, "\n"))
+	fmt.Fprintf(&buf, "-- +migrate Down\n%s\n", strings.Join(down, "\n"))
+	return buf.Bytes(), nil
+}
+
+// createTable returns the statements creating the table and its indexes.
+func createTable(schema tableSchema) []string {
+	var lines []string
+	for _, column := range schema.Columns {
+		lines = append(lines, "    "+columnDefinition(column))
+	}
+	if len(schema.PrimaryKey) > 0 {
+		lines = append(lines, fmt.Sprintf("    PRIMARY KEY (%s)", quoteIdents(schema.PrimaryKey)))
+	}
+
+	stmts := []string{fmt.Sprintf("CREATE TABLE %s (\n%s\n);", quoteIdent(schema.Name), strings.Join(lines, ",\n"))}
+	for _, column := range schema.Columns {
+		if column.Index {
+			stmts = append(stmts, createIndex(schema.Name, column.Name))
+		}
+	}
+	return stmts
+}
+
+// alterTable returns the statements altering the table of the prev schema to the next one.
+// The constraints and indexes of the dropped columns are dropped by the database.
+// The rows of the table get the zero values of the added NOT NULL columns
+// and of the columns becoming NOT NULL, see zeroValue.
+func alterTable(prev, next tableSchema) []string {
+	var stmts []string
+	table := quoteIdent(next.Name)
+	alter := func(format string, args ...interface{}) {
+		stmts = append(stmts, "ALTER TABLE "+table+" "+fmt.Sprintf(format, args...)+";")
+	}
+
+	primaryKeyChanged := quoteIdents(prev.PrimaryKey) != quoteIdents(next.PrimaryKey)
+	if primaryKeyChanged && len(prev.PrimaryKey) > 0 {
+		alter("DROP CONSTRAINT %s", quoteIdent(next.Name+"_pkey"))
+	}
+
+	for _, old := range prev.Columns {
+		column, ok := next.column(old.Name)
+		if !ok {
+			alter("DROP COLUMN %s", quoteIdent(old.Name))
+			continue
+		}
+		if old.Index && !column.Index {
+			stmts = append(stmts, fmt.Sprintf("DROP INDEX %s;", quoteIdent(indexName(next.Name, old.Name))))
+		}
+		if old.Unique && !column.Unique {
+			alter("DROP CONSTRAINT %s", quoteIdent(next.Name+"_"+old.Name+"_key"))
+		}
+	}
+
+	for _, column := range next.Columns {
+		old, ok := prev.column(column.Name)
+		name := quoteIdent(column.Name)
+		zero, hasZero := zeroValue(column.Type)
+		if !ok {
+			def := columnDefinition(columnSchema{Name: column.Name, Type: column.Type, Nullable: column.Nullable})
+			if column.Nullable || !hasZero {
+				if !column.Nullable && baseType(column.Type) == column.Type {
+					log.Printf("[WARN] table %s: zero value of column %s of type %s is unknown, fill the existing rows by hand\n",
+						next.Name, column.Name, column.Type)
+				}
+				alter("ADD COLUMN %s", def)
+			} else {
+				// the default fills the existing rows, the values of the new ones are set by the code
+				alter("ADD COLUMN %s DEFAULT %s", def, zero)
+				alter("ALTER COLUMN %s DROP DEFAULT", name)
+			}
+		} else {
+			if typ := baseType(column.Type); typ != baseType(old.Type) {
+				alter("ALTER COLUMN %s TYPE %s USING %s::%s", name, typ, name, typ)
+			}
+			if old.Nullable && !column.Nullable {
+				if hasZero {
+					stmts = append(stmts, fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IS NULL;", table, name, zero, name))
+				}
+				alter("ALTER COLUMN %s SET NOT NULL", name)
+			}
+			if !old.Nullable && column.Nullable {
+				alter("ALTER COLUMN %s DROP NOT NULL", name)
+			}
+		}
+
+		if column.Unique && !old.Unique {
+			alter("ADD CONSTRAINT %s UNIQUE (%s)", quoteIdent(next.Name+"_"+column.Name+"_key"), quoteIdent(column.Name))
+		}
+		if column.Index && !old.Index {
+			stmts = append(stmts, createIndex(next.Name, column.Name))
+		}
+	}
+
+	if primaryKeyChanged && len(next.PrimaryKey) > 0 {
+		alter("ADD PRIMARY KEY (%s)", quoteIdents(next.PrimaryKey))
+	}
+	return stmts
+}
+
+// columnDefinition returns the definition of the column for the CREATE or ALTER TABLE,
+// the unique constraint is named by PostgreSQL like `users_email_key`.
+func columnDefinition(column columnSchema) string {
+	def := quoteIdent(column.Name) + " " + column.Type
+	if !column.Nullable {
+		def += " NOT NULL"
+	}
+	if column.Unique {
+		def += " UNIQUE"
+	}
+	return def
+}
+
+// zeroValues are the literals of the zero values of the Go types by their column types.
+var zeroValues = map[string]string{
+	"text":                     "''",
+	"boolean":                  "false",
+	"smallint":                 "0",
+	"integer":                  "0",
+	"bigint":                   "0",
+	"real":                     "0",
+	"double precision":         "0",
+	"timestamp with time zone": "'0001-01-01 00:00:00+00'",
+	"bytea":                    "''",
+	"jsonb":                    "'null'",
+	"uuid":                     "'00000000-0000-0000-0000-000000000000'",
+}
+
+// zeroValue returns the literal of the zero value of the column type. Serial columns
+// are filled by the database and the zero values of the custom types are unknown,
+// so false is returned for them.
+func zeroValue(typ string) (string, bool) {
+	zero, ok := zeroValues[typ]
+	return zero, ok
+}
+
+// baseType returns the integer type of the serial one or the type as is,
+// serial types can't be used to change the type of the column.
+func baseType(typ string) string {
+	for base, serial := range serialTypes {
+		if typ == serial {
+			return base
+		}
+	}
+	return typ
+}
+
+func createIndex(table, column string) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s);", quoteIdent(indexName(table, column)), quoteIdent(table), quoteIdent(column))
+}
+
+func indexName(table, column string) string {
+	return table + "_" + column + "_idx"
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func quoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,164 @@
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/templates"
+)
+
+func TestGenerateMigrations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeModels := func(src string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "models.go"), []byte("package models\n\n"+src), 0644))
+	}
+	writeModels("type UserRole struct {\n\tID int64 `db:\"id,pk\"`\n\tName *string `db:\"name,unique\"`\n\tSkip int\n}\n")
+
+	config := configs.MigrationConfig{Time: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	config.Dir = dir
+	config.Types = []string{"UserRole"}
+	config.OutputDir = "migrations"
+	config.Command = "migration --type UserRole"
+	require.NoError(t, config.Validate())
+
+	files, err := GenerateMigrations(config)
+	require.NoError(t, err)
+	createPath := filepath.Join(dir, "migrations", "20200102030405_create_user_roles.sql")
+	assert.Equal(t, `-- generated by forge migration --type UserRole
+-- forge:schema {"table":"user_roles","columns":[{"name":"id","type":"bigserial"},{"name":"name","type":"text","nullable":true,"unique":true}],"primaryKey":["id"]}
+
+-- +migrate Up
+CREATE TABLE "user_roles" (
+    "id" bigserial NOT NULL,
+    "name" text UNIQUE,
+    PRIMARY KEY ("id")
+);
+
+-- +migrate Down
+DROP TABLE "user_roles";
+`, string(files[createPath]))
+	require.NoError(t, files.Write())
+
+	// the same schema isn't migrated
+	files, err = GenerateMigrations(config)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	writeModels("type UserRole struct {\n\tID int64 `db:\"id,pk\"`\n\tName string `db:\"name\"`\n\tLevel int32 `db:\"level,index\"`\n}\n")
+	config.Time = config.Time.Add(time.Hour)
+	files, err = GenerateMigrations(config)
+	require.NoError(t, err)
+	alterPath := filepath.Join(dir, "migrations", "20200102040405_alter_user_roles.sql")
+	assert.Equal(t, []string{alterPath}, files.Paths())
+	assert.Contains(t, string(files[alterPath]), `
+-- +migrate Up
+ALTER TABLE "user_roles" DROP CONSTRAINT "user_roles_name_key";
+UPDATE "user_roles" SET "name" = '' WHERE "name" IS NULL;
+ALTER TABLE "user_roles" ALTER COLUMN "name" SET NOT NULL;
+ALTER TABLE "user_roles" ADD COLUMN "level" integer NOT NULL DEFAULT 0;
+ALTER TABLE "user_roles" ALTER COLUMN "level" DROP DEFAULT;
+CREATE INDEX "user_roles_level_idx" ON "user_roles" ("level");
+
+-- +migrate Down
+ALTER TABLE "user_roles" DROP COLUMN "level";
+ALTER TABLE "user_roles" ALTER COLUMN "name" DROP NOT NULL;
+ALTER TABLE "user_roles" ADD CONSTRAINT "user_roles_name_key" UNIQUE ("name");
+`)
+	require.NoError(t, files.Write())
+
+	// the dropped NOT NULL column is restored with the zero values
+	writeModels("type UserRole struct {\n\tID int64 `db:\"id,pk\"`\n\tName string `db:\"name\"`\n}\n")
+	config.Time = config.Time.Add(time.Hour)
+	files, err = GenerateMigrations(config)
+	require.NoError(t, err)
+	dropPath := filepath.Join(dir, "migrations", "20200102050405_alter_user_roles.sql")
+	assert.Contains(t, string(files[dropPath]), `
+-- +migrate Up
+ALTER TABLE "user_roles" DROP COLUMN "level";
+
+-- +migrate Down
+ALTER TABLE "user_roles" ADD COLUMN "level" integer NOT NULL DEFAULT 0;
+ALTER TABLE "user_roles" ALTER COLUMN "level" DROP DEFAULT;
+CREATE INDEX "user_roles_level_idx" ON "user_roles" ("level");
+`)
+}
+
+func TestGenerateMigrations_enum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the enums are stored by the names, the generated Value returns them;
+	// Role isn't generated yet, so it's found by the directive
+	src := `package models
+
+import "database/sql/driver"
+
+//forge:enum
+type Role int
+
+type Size int
+
+func (s Size) String() string { return "" }
+
+func (s Size) Value() (driver.Value, error) { return s.String(), nil }
+
+type Level int
+
+type User struct {
+	ID    int64 ` + "`db:\"id,pk\"`" + `
+	Role  Role  ` + "`db:\"role\"`" + `
+	Size  *Size ` + "`db:\"size\"`" + `
+	Level Level ` + "`db:\"level\"`" + `
+}
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644))
+
+	config := configs.MigrationConfig{Time: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	config.Dir = dir
+	config.Types = []string{"User"}
+	config.OutputDir = "migrations"
+	require.NoError(t, config.Validate())
+
+	files, err := GenerateMigrations(config)
+	require.NoError(t, err)
+	assert.Contains(t, string(files[filepath.Join(dir, "migrations", "20200102030405_create_users.sql")]), `
+CREATE TABLE "users" (
+    "id" bigserial NOT NULL,
+    "role" text NOT NULL,
+    "size" text,
+    "level" bigint NOT NULL,
+    PRIMARY KEY ("id")
+);
+`)
+}
+
+func Test_columnType(t *testing.T) {
+	for _, c := range []struct {
+		field    templates.Field
+		typ      string
+		nullable bool
+	}{
+		{templates.Field{FType: "*time.Time", Pointer: true}, "timestamp with time zone", true},
+		{templates.Field{FType: "sql.NullInt64"}, "bigint", true},
+		{templates.Field{FType: "Size", Basic: "uint8"}, "smallint", false},
+		{templates.Field{FType: "*Role", Basic: "int", Enum: true, Pointer: true}, "text", true},
+		{templates.Field{FType: "[]byte", Slice: true}, "bytea", false},
+		{templates.Field{FType: "map[string]int", Map: true}, "jsonb", false},
+		{templates.Field{FType: "Item"}, "jsonb", false},
+	} {
+		typ, nullable := columnType(c.field)
+		assert.Equal(t, c.typ, typ, c.field.FType)
+		assert.Equal(t, c.nullable, nullable, c.field.FType)
+	}
+}
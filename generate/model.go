@@ -1,85 +1,388 @@
 package generate
 
 import (
-	"flag"
+	"bytes"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"os"
+	"go/ast"
+	"go/format"
+	goparser "go/parser"
+	"go/scanner"
+	"go/token"
 	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/ast/astutil"
 
 	"github.com/lancer-kit/forge/configs"
 	"github.com/lancer-kit/forge/parser"
 	"github.com/lancer-kit/forge/templates"
 )
 
-// todo: refactor
-// 1. Analyze model(s)
-// 2. Gen by template
-// 3. Write file
+// Model generates the code for the types listed in the config
+// by the template and writes it.
 func Model(config configs.ModelConfig) error {
+	files, err := GenerateModels(config)
+	if err != nil {
+		return err
+	}
+	return files.Apply(config.Check)
+}
+
+// GenerateModels generates the code for the types like Model, but returns
+// the files instead of writing them.
+func GenerateModels(config configs.ModelConfig) (Files, error) {
 	// Only one directory at a time can be processed, and the default is ".".
 	dir := "."
-
-	if args := flag.Args(); len(args) >= 1 {
-		dir = args[0]
+	if config.Dir != "" {
+		dir = config.Dir
 	}
 
 	dir, err := filepath.Abs(dir)
 	if err != nil {
-		return fmt.Errorf("unable to determine absolute filepath for requested path %s: %v",
+		return nil, fmt.Errorf("unable to determine absolute filepath for requested path %s: %v",
 			dir, err)
 	}
 
-	if len(config.Types) == 1 {
-		config.MergeSpecs = false
+	tmpls, err := openModelTemplates(dir, config)
+	if err != nil {
+		return nil, err
+	}
+	files, err := modelOutputs(dir, config, tmpls)
+	if err != nil {
+		return nil, &Error{Dir: dir, Err: err}
 	}
 
-	// need to remove already generated files for types
-	// this is need for correct search of predefined by user
-	// type vars and methods
-	for _, typeName := range config.Types {
-		// Remove safe because we already check is path valid
-		// and don't care about is present file - we need to remove it.
-		os.Remove(config.GetPath(typeName, dir))
+	key := newCacheKey("model")
+	options := config
+	options.Check = false
+	key.add(dir, fmt.Sprintf("%#v", options))
+	for _, tmpl := range tmpls {
+		src, err := templates.ReadTemplate(tmpl.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open template: %s", err.Error())
+		}
+		key.add(tmpl.Path, string(src))
+		partials, err := templates.Partials(tmpl.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open template: %s", err.Error())
+		}
+		for _, partial := range partials {
+			if err := key.addFile(partial); err != nil {
+				return nil, fmt.Errorf("unable to open template: %s", err.Error())
+			}
+		}
+	}
+	if err := key.addSources(dir, files.Paths()); err != nil {
+		return nil, &Error{Dir: dir, Err: fmt.Errorf("hashing sources: %v", err)}
+	}
+	for _, outDir := range outputDirs(dir, files) {
+		// package name of the output is taken from its files
+		if err := key.addSources(outDir, files.Paths()); err != nil {
+			return nil, &Error{Dir: dir, Err: fmt.Errorf("hashing sources: %v", err)}
+		}
 	}
 
-	pkg, err := parser.ParsePackage(dir)
+	what := dir + ": " + strings.Join(config.Types, ", ")
+	return cached(config.NoCache, dir, key, what, func() (Files, error) {
+		return renderModels(dir, config, tmpls, files, func(exclude []string) (*parser.Package, error) {
+			return parser.ParsePackage(dir, exclude...)
+		})
+	})
+}
+
+// GenerateModelsFromFiles generates the code for the types declared
+// in the already parsed files of one package. Output paths are relative
+// to the config.Dir, the cache is never used.
+func GenerateModelsFromFiles(fset *token.FileSet, files []*ast.File, config configs.ModelConfig) (Files, error) {
+	tmpls, err := openModelTemplates(config.Dir, config)
 	if err != nil {
-		return fmt.Errorf("parsing package: %v", err)
+		return nil, err
 	}
+	outputs, err := modelOutputs(config.Dir, config, tmpls)
+	if err != nil {
+		return nil, &Error{Dir: config.Dir, Err: err}
+	}
+
+	load := func(exclude []string) (*parser.Package, error) {
+		return parser.ParseFiles(fset, excludeParsed(fset, files, exclude))
+	}
+	return renderModels(config.Dir, config, tmpls, outputs, load)
+}
 
-	tmpl, err := templates.OpenTemplate(config.TPath)
+// openModelTemplates parses all templates of the config.
+func openModelTemplates(dir string, config configs.ModelConfig) ([]*templates.ModelTemplate, error) {
+	paths, err := config.TemplatePaths()
 	if err != nil {
-		return fmt.Errorf("unable to open template: %s", err.Error())
+		return nil, fmt.Errorf("unable to open template: %s", err.Error())
 	}
 
-	// Run generate for each type.
+	var errs ErrorList
+	var tmpls []*templates.ModelTemplate
+	for _, path := range paths {
+		tmpl, err := templates.OpenModelTemplate(path)
+		if err != nil {
+			errs.add(dir, "", "unable to open template", err)
+			continue
+		}
+		tmpls = append(tmpls, tmpl)
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return tmpls, nil
+}
+
+// modelOutputs returns the outputs of the templates for the types with nil content,
+// already generated files for types are excluded from the parsing, this is need
+// for correct search of predefined by user type vars and methods.
+func modelOutputs(dir string, config configs.ModelConfig, tmpls []*templates.ModelTemplate) (Files, error) {
+	files := Files{}
+	owners := map[string]string{}
+	for _, tmpl := range tmpls {
+		for _, typeName := range config.Types {
+			path, err := modelPath(dir, config, tmpl, typeName)
+			if err != nil {
+				return nil, err
+			}
+
+			owner := tmpl.Path + " for " + typeName
+			if other, ok := owners[path]; ok {
+				return nil, fmt.Errorf("%s and %s are written into the same file %s", other, owner, path)
+			}
+			owners[path] = owner
+			files[path] = nil
+		}
+	}
+	return files, nil
+}
+
+// modelPath returns the path of the output of the template for the type,
+// the front matter of the template overrides the output options of the config.
+func modelPath(dir string, config configs.ModelConfig, tmpl *templates.ModelTemplate, typeName string) (string, error) {
+	output := config.BaseConfig
+	if tmpl.Package != "" {
+		output.OutputDir = tmpl.Package
+	}
+
+	name, err := tmpl.OutputName(typeName)
+	if err != nil {
+		return "", err
+	}
+	if name != "" {
+		return filepath.Join(output.GetOutputDir(dir), name), nil
+	}
+
+	if output.OutputName == "" && output.OutputPrefix == "" && output.OutputSuffix == "" {
+		return "", fmt.Errorf("%s: the output isn't set by the front matter, sufix or prefix should be passed", tmpl.Path)
+	}
+	return output.GetPath(typeName, dir), nil
+}
+
+// outputDirs returns the sorted directories of the files except the dir.
+func outputDirs(dir string, files Files) []string {
+	var result []string
+	seen := map[string]bool{dir: true}
+	for _, path := range files.Paths() {
+		if outDir := filepath.Dir(path); !seen[outDir] {
+			seen[outDir] = true
+			result = append(result, outDir)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// renderModels renders the templates for the types into the files,
+// which already list the outputs to be excluded from the parsing.
+// The package is parsed once for all templates.
+func renderModels(dir string, config configs.ModelConfig, tmpls []*templates.ModelTemplate, files Files, load loadFunc) (Files, error) {
+	var errs ErrorList
+	pkg, err := load(files.Paths())
+	if err != nil {
+		errs.add(dir, "", "parsing package", err)
+		return nil, errs.Err()
+	}
+
+	command := config.Command
+	if command == "" {
+		command = "model --type " + strings.Join(config.Types, ",")
+	}
+
+	models := map[string]*templates.ModelSpec{}
 	for _, typeName := range config.Types {
 		spec, err := pkg.FindStructureSpec(typeName)
 		if err != nil {
-			return fmt.Errorf("finding values for type %v: %s", typeName, err.Error())
-		}
-		if spec == nil {
-			log.Printf("[WARN] definition of the type %s isn't found, skip it. \n", typeName)
+			errs.add(dir, typeName, "finding structure", err)
 			continue
 		}
+		if config.Flatten {
+			spec = spec.Flatten()
+		}
 
 		model, err := templates.FigureOut(spec)
 		if err != nil {
-			return fmt.Errorf("FigureOut for type %v is failed: %s", typeName, err.Error())
+			errs.add(dir, typeName, "", err)
+			continue
 		}
+		model.Command = command
+		models[typeName] = model
+	}
 
-		model.Package = pkg.Name
-		newRawFile, err := model.Exec(tmpl)
-		if err != nil {
-			return fmt.Errorf("exec template for type %v failed: %v", typeName, err)
+	outputs := map[string]outputPackage{}
+	for _, tmpl := range tmpls {
+		for _, typeName := range config.Types {
+			model, ok := models[typeName]
+			if !ok {
+				continue
+			}
+
+			path, err := modelPath(dir, config, tmpl, typeName)
+			if err != nil {
+				errs.add(dir, typeName, "", err)
+				continue
+			}
+			output, ok := outputs[filepath.Dir(path)]
+			if !ok {
+				output, err = outputPackageOf(pkg, dir, filepath.Dir(path), files.Paths())
+				if err != nil {
+					errs.add(dir, typeName, "", err)
+					continue
+				}
+				outputs[filepath.Dir(path)] = output
+			}
+
+			src, err := renderModel(*model, tmpl, path, pkg.Name, output)
+			if err != nil {
+				errs.add(dir, typeName, "", err)
+				continue
+			}
+			files[path] = src
 		}
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// renderModel executes the template for the model placed into the output package
+// and formats the result, see formatModel.
+func renderModel(model templates.ModelSpec, tmpl *templates.ModelTemplate, path, pkgName string, output outputPackage) ([]byte, error) {
+	model.Package = output.Name
+	if output.SourceImport != "" {
+		model = model.Qualify(pkgName)
+		model.SourceImport = output.SourceImport
+	}
 
-		if err := ioutil.WriteFile(config.GetPath(typeName, dir), []byte(newRawFile), 0644); err != nil {
-			return fmt.Errorf("writing output failed: %s", err)
+	newRawFile, err := model.Exec(tmpl.Template)
+	if err != nil {
+		return nil, templates.TemplateError(tmpl.Path, err)
+	}
+
+	var fieldImports []string
+	for _, field := range model.Fields {
+		for _, importPath := range []string{field.ImportPath, field.KeyImportPath} {
+			if importPath != "" {
+				fieldImports = append(fieldImports, importPath)
+			}
 		}
 	}
 
-	return nil
+	src, err := formatModel(path, []byte(newRawFile), output.SourceImport, fieldImports)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %v", tmpl.Path, err)
+	}
+	return src, nil
+}
+
+// formatModel formats the generated Go source of the file and fixes its imports
+// like goimports: the used packages of the field types and the standard ones
+// are imported and the unused ones are removed, see fixImports.
+// The import of the source package is added, if it's set.
+func formatModel(path string, src []byte, sourceImport string, fieldImports []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, path, src, goparser.ParseComments)
+	if err != nil {
+		return nil, invalidSource(path, src, err)
+	}
+
+	if sourceImport != "" {
+		astutil.AddImport(fset, file, sourceImport)
+	}
+	fixImports(fset, file, path, fieldImports)
+	ast.SortImports(fset, file)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("formatting output: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// invalidSource describes the syntax error of the generated source
+// by the position in the output file and the failing line.
+func invalidSource(path string, src []byte, err error) error {
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) == 0 {
+		return fmt.Errorf("output is not valid Go code: %v", err)
+	}
+
+	pos := list[0].Pos
+	msg := fmt.Sprintf("output is not valid Go code: %s:%d:%d: %s", filepath.Base(path), pos.Line, pos.Column, list[0].Msg)
+	if lines := strings.Split(string(src), "\n"); pos.Line > 0 && pos.Line <= len(lines) {
+		msg += fmt.Sprintf("\n\t%d | %s", pos.Line, lines[pos.Line-1])
+	}
+	return errors.New(msg)
+}
+
+// outputPackage describes the package, which the output files belong to.
+type outputPackage struct {
+	Name string
+	// SourceImport is an import path of the source package,
+	// it's set only if the output package is another one.
+	SourceImport string
+}
+
+// outputPackageOf returns the package of the output directory. The name is taken
+// from the existing Go files or, for a new package, from the directory name.
+func outputPackageOf(pkg *parser.Package, dir, outDir string, exclude []string) (outputPackage, error) {
+	if outDir == dir {
+		return outputPackage{Name: pkg.Name}, nil
+	}
+
+	importPath, err := parser.ImportPath(dir)
+	if err != nil {
+		return outputPackage{}, fmt.Errorf("resolving import path of the package: %v", err)
+	}
+
+	name, err := parser.PackageName(outDir, exclude...)
+	if err != nil {
+		return outputPackage{}, fmt.Errorf("resolving output package: %v", err)
+	}
+	if name == "" {
+		name = packageNameOfDir(outDir)
+	}
+	if name == "" {
+		return outputPackage{}, fmt.Errorf("unable to name the package of the directory %s", outDir)
+	}
+
+	return outputPackage{Name: name, SourceImport: importPath}, nil
+}
+
+// packageNameOfDir makes the package name of the directory name
+// by removing all symbols, which can't be used in the name.
+func packageNameOfDir(dir string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(dir))
+
+	return strings.TrimLeftFunc(name, unicode.IsDigit)
 }
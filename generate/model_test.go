@@ -0,0 +1,123 @@
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lancer-kit/forge/configs"
+)
+
+func TestGenerateModels_templates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sources := map[string]string{
+		"models.go": "package models\n\ntype User struct {\n\tID int64 `db:\"id\"`\n}\n",
+		"tmpls/repo.tmpl": "---\noutput: \"{{ snake .TypeName }}_repo.go\"\n---\n" +
+			"package {{ .Package }}\n\ntype {{ .TypeName }}Repo struct{}\n",
+		"tmpls/dto.tmpl": "package {{ .Package }}\n\ntype {{ .TypeName }}DTO struct{}\n",
+	}
+	for name, src := range sources {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(src), 0644))
+	}
+
+	config := configs.ModelConfig{TPaths: []string{filepath.Join(dir, "tmpls")}}
+	config.Dir = dir
+	config.Types = []string{"User"}
+	config.OutputSuffix = "_dto"
+	config.NoCache = true
+	require.NoError(t, config.Validate())
+
+	files, err := GenerateModels(config)
+	require.NoError(t, err)
+	assert.Equal(t, Files{
+		filepath.Join(dir, "user_dto.go"):  []byte("package models\n\ntype UserDTO struct{}\n"),
+		filepath.Join(dir, "user_repo.go"): []byte("package models\n\ntype UserRepo struct{}\n"),
+	}, files)
+
+	config.OutputSuffix = "_repo"
+	_, err = GenerateModels(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "are written into the same file")
+}
+
+func TestGenerateModels_validateDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := "package models\n\ntype User struct {\n\tName string `validate:\"required\"`\n}\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644))
+
+	// the method can be declared only in the package of the type
+	config := configs.ModelConfig{TPaths: []string{"builtin:validate"}}
+	config.Dir = dir
+	config.OutputDir = "../api"
+	config.Types = []string{"User"}
+	config.NoCache = true
+	require.NoError(t, config.Validate())
+
+	files, err := GenerateModels(config)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "user_validate.go")
+	assert.Equal(t, []string{path}, files.Paths())
+	assert.Contains(t, string(files[path]), "// Code generated by forge model --type User; DO NOT EDIT.\n\npackage models\n")
+	assert.Contains(t, string(files[path]), "func (u User) Validate() error {")
+
+	// the output is recognized as generated, so it's excluded from the parsing
+	require.NoError(t, files.Write())
+	generated, err := generatedFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, generated)
+}
+
+func TestModelConfig_accessorDir(t *testing.T) {
+	// the accessor stays in the package of the type, it can't call the queries moved by the dir
+	config := configs.ModelConfig{TPaths: []string{"builtin:armory-q", "builtin:armory-q-accessor"}}
+	config.Types = []string{"User"}
+	config.OutputDir = "../repo"
+	assert.EqualError(t, config.Validate(),
+		"dir: builtin:armory-q-accessor requires builtin:armory-q in the package of the type, generate them without dir")
+
+	config.OutputDir = ""
+	assert.NoError(t, config.Validate())
+	config.OutputDir = "../repo"
+	config.TPaths = []string{"builtin:armory-q"}
+	assert.NoError(t, config.Validate())
+}
+
+func Test_formatModel(t *testing.T) {
+	src := "package models\nimport \"strings\"\nfunc Now() time.Time { return time.Now() }\n"
+	formatted, err := formatModel("/tmp/models/user_q.go", []byte(src), "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "package models\n\nimport \"time\"\n\nfunc Now() time.Time { return time.Now() }\n", string(formatted))
+
+	// only the standard packages are imported, the globals of the package are not packages
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "log.go"), []byte("package models\n\nvar log = struct{ Print func(...interface{}) }{}\n"), 0644))
+	src = "package models\nfunc New() { log.Print(shortid.New(), json.Valid(nil)) }\n"
+	formatted, err = formatModel(filepath.Join(dir, "user_q.go"), []byte(src), "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "package models\n\nimport \"encoding/json\"\n\nfunc New() { log.Print(shortid.New(), json.Valid(nil)) }\n", string(formatted))
+
+	// the packages of the field types take precedence over the standard ones
+	src = "package api\nvar ids []uuid.UUID\n"
+	formatted, err = formatModel(filepath.Join(dir, "user_q.go"), []byte(src), "", []string{"example.com/uuid"})
+	require.NoError(t, err)
+	assert.Equal(t, "package api\n\nimport \"example.com/uuid\"\n\nvar ids []uuid.UUID\n", string(formatted))
+
+	src = "package models\n\nfunc Users() {\n\tx := ID Name\n}\n"
+	_, err = formatModel("/tmp/models/user_q.go", []byte(src), "", nil)
+	require.Error(t, err)
+	assert.Equal(t, "output is not valid Go code: user_q.go:4:10: expected ';', found Name\n\t4 | \tx := ID Name", err.Error())
+}
@@ -0,0 +1,537 @@
+package generate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+// OpenAPI generates the OpenAPI 3 schemas of the types listed in the config
+// and merges them into the OpenAPI document of the package.
+func OpenAPI(config configs.OpenAPIConfig) error {
+	files, err := GenerateOpenAPI(config)
+	if err != nil {
+		return err
+	}
+	return files.Apply(config.Check)
+}
+
+// GenerateOpenAPI generates the schemas like OpenAPI, but returns the document
+// instead of writing it. If the types are not listed, all exported structs of the package
+// are used. Structs and enums of the package referred by the fields are added too.
+// The existing document keeps its other parts, only the schemas of the types are replaced,
+// but the comments of the document are lost, see mergeSchemas.
+func GenerateOpenAPI(config configs.OpenAPIConfig) (Files, error) {
+	// Only one directory at a time can be processed, and the default is ".".
+	dir := "."
+	if config.Dir != "" {
+		dir = config.Dir
+	}
+	path := config.GetDocumentPath(dir)
+
+	var errs ErrorList
+	pkg, err := parser.ParsePackage(dir)
+	if err != nil {
+		errs.add(dir, "", "parsing package", err)
+		return nil, errs.Err()
+	}
+
+	builder := newSchemaBuilder(dir, pkg)
+	typeNames := config.Types
+	if len(typeNames) == 0 {
+		// structs without properties, like the query builders, aren't marshaled;
+		// they are checked by the separate builder to not report the errors twice
+		probe := newSchemaBuilder(dir, pkg)
+		for _, decl := range pkg.Types() {
+			st, ok := decl.Underlying.(*types.Struct)
+			if ok && ast.IsExported(decl.Name) && len(probe.structSchema(st).Properties) > 0 {
+				typeNames = append(typeNames, decl.Name)
+			}
+		}
+	}
+	for _, typeName := range typeNames {
+		decl, ok := builder.names[typeName]
+		if !ok {
+			log.Printf("[WARN] definition of the type %s isn't found, skip it. \n", typeName)
+			continue
+		}
+		builder.component(decl)
+	}
+	errs = append(errs, builder.errs...)
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	src, err := mergeSchemas(path, pkg.Name, builder.schemas)
+	if err != nil {
+		return nil, &Error{Dir: dir, Err: err}
+	}
+	return Files{path: src}, nil
+}
+
+// openAPIVersion is the version of the OpenAPI specification of the new documents.
+const openAPIVersion = "3.0.3"
+
+// openAPISchema is the OpenAPI 3 schema object.
+type openAPISchema struct {
+	Ref                  string         `yaml:"$ref,omitempty"`
+	Type                 string         `yaml:"type,omitempty"`
+	Format               string         `yaml:"format,omitempty"`
+	Pattern              string         `yaml:"pattern,omitempty"`
+	Enum                 []interface{}  `yaml:"enum,omitempty"`
+	Minimum              *float64       `yaml:"minimum,omitempty"`
+	Maximum              *float64       `yaml:"maximum,omitempty"`
+	MinLength            *uint64        `yaml:"minLength,omitempty"`
+	MaxLength            *uint64        `yaml:"maxLength,omitempty"`
+	MinItems             *uint64        `yaml:"minItems,omitempty"`
+	MaxItems             *uint64        `yaml:"maxItems,omitempty"`
+	Items                *openAPISchema `yaml:"items,omitempty"`
+	Properties           yaml.MapSlice  `yaml:"properties,omitempty"`
+	AdditionalProperties *openAPISchema `yaml:"additionalProperties,omitempty"`
+	Required             []string       `yaml:"required,omitempty"`
+}
+
+// schemaBuilder builds the schemas of the types like encoding/json marshals them.
+// Structs and enums declared in the package are the components referred by $ref.
+type schemaBuilder struct {
+	dir   string
+	pkg   *parser.Package
+	decls map[types.Type]parser.TypeDecl
+	names map[string]parser.TypeDecl
+	// schemas are the components by the type names.
+	schemas map[string]*openAPISchema
+	// embedding are the embedded named structs, which are expanded at the moment.
+	embedding map[types.Object]bool
+	errs      ErrorList
+}
+
+func newSchemaBuilder(dir string, pkg *parser.Package) *schemaBuilder {
+	builder := &schemaBuilder{
+		dir:       dir,
+		pkg:       pkg,
+		decls:     map[types.Type]parser.TypeDecl{},
+		names:     map[string]parser.TypeDecl{},
+		schemas:   map[string]*openAPISchema{},
+		embedding: map[types.Object]bool{},
+	}
+	for _, decl := range pkg.Types() {
+		builder.decls[decl.Type] = decl
+		builder.names[decl.Name] = decl
+	}
+	return builder
+}
+
+// component adds the schema of the type declared in the package
+// into the components and returns the reference to it.
+func (b *schemaBuilder) component(decl parser.TypeDecl) *openAPISchema {
+	ref := &openAPISchema{Ref: "#/components/schemas/" + decl.Name}
+	if _, ok := b.schemas[decl.Name]; ok {
+		return ref
+	}
+
+	// the placeholder stops the recursion of the self-referencing types
+	schema := &openAPISchema{}
+	b.schemas[decl.Name] = schema
+	if isEnum(decl) {
+		*schema = *b.enumSchema(decl)
+	} else {
+		*schema = *b.schemaOf(decl.Underlying)
+	}
+	return ref
+}
+
+// schemaOf returns the schema of the JSON representation of the type.
+func (b *schemaBuilder) schemaOf(typ types.Type) *openAPISchema {
+	switch t := typ.(type) {
+	case *types.Pointer:
+		return b.schemaOf(t.Elem())
+	case *types.Named:
+		decl, local := b.decls[t]
+		if local && isEnum(decl) {
+			return b.component(decl)
+		}
+		if schema, ok := marshalerSchema(t); ok {
+			return schema
+		}
+		if _, ok := t.Underlying().(*types.Struct); ok && local {
+			return b.component(decl)
+		}
+		return b.schemaOf(t.Underlying())
+	case *types.Basic:
+		return basicSchema(t)
+	case *types.Slice:
+		if basic, ok := t.Elem().(*types.Basic); ok && basic.Kind() == types.Byte {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case *types.Array:
+		return &openAPISchema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case *types.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case *types.Struct:
+		return b.structSchema(t)
+	default:
+		// interfaces can hold any value
+		return &openAPISchema{}
+	}
+}
+
+// structSchema returns the schema of the struct. Properties are named by the `json` tags,
+// they are required unless they are pointers or have the `omitempty` option,
+// the `required` rule of the `validate` tag makes them required anyway.
+func (b *schemaBuilder) structSchema(st *types.Struct) *openAPISchema {
+	schema := &openAPISchema{Type: "object"}
+	var embedded []*openAPISchema
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		name, options := jsonTag(tag.Get("json"))
+		if name == "-" && options == nil {
+			continue
+		}
+
+		if field.Embedded() && name == "" {
+			fieldType := derefType(field.Type())
+			if inner, ok := fieldType.Underlying().(*types.Struct); ok {
+				// like encoding/json does, the struct embedding itself
+				// is expanded once, its repeated fields are hidden anyway
+				var obj types.Object
+				if named, ok := fieldType.(*types.Named); ok {
+					obj = named.Obj()
+				}
+				if obj != nil && b.embedding[obj] {
+					continue
+				}
+				if obj != nil {
+					b.embedding[obj] = true
+				}
+				embedded = append(embedded, b.structSchema(inner))
+				delete(b.embedding, obj)
+				continue
+			}
+		}
+		if !field.Exported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name()
+		}
+
+		_, pointer := field.Type().(*types.Pointer)
+		property := b.schemaOf(field.Type())
+		if options["string"] && (property.Type == "integer" || property.Type == "number" || property.Type == "boolean") {
+			property = &openAPISchema{Type: "string"}
+		}
+		rules, err := templates.ParseValidateTag(tag.Get("validate"))
+		if err != nil {
+			b.errs.add(b.dir, "", "", fmt.Errorf("field %s: %v", field.Name(), err))
+		}
+		applyRules(property, rules)
+		required := !pointer && !options["omitempty"]
+		for _, rule := range rules {
+			required = required || rule.Name == "required"
+		}
+
+		schema.Properties = append(schema.Properties, yaml.MapItem{Key: name, Value: property})
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	// like encoding/json does, fields of the struct hide the promoted ones
+	for _, inner := range embedded {
+		for _, item := range inner.Properties {
+			if hasProperty(schema, item.Key) {
+				continue
+			}
+			schema.Properties = append(schema.Properties, item)
+			for _, name := range inner.Required {
+				if name == item.Key {
+					schema.Required = append(schema.Required, name)
+				}
+			}
+		}
+	}
+	return schema
+}
+
+// enumSchema returns the schema of the enum declared in the package. Enums marshaled to JSON
+// by forge enum are the strings listed by the generated def<Type>ValueToName map,
+// the output of other JSON marshalers is unknown, so any value is allowed.
+// Enums without the marshaler are the values of the constants.
+func (b *schemaBuilder) enumSchema(decl parser.TypeDecl) *openAPISchema {
+	if types.NewMethodSet(types.NewPointer(decl.Type)).Lookup(nil, "MarshalJSON") != nil {
+		strs, generated := b.pkg.StringsOfType(decl.Name)
+		if !generated {
+			return &openAPISchema{}
+		}
+		schema := &openAPISchema{Type: "string"}
+		for _, str := range strs {
+			schema.Enum = append(schema.Enum, str)
+		}
+		return schema
+	}
+
+	schema := basicSchema(decl.Underlying.(*types.Basic))
+	scope := decl.Type.(*types.Named).Obj().Pkg().Scope()
+	seen := map[string]bool{}
+	for _, name := range decl.Constants {
+		value := scope.Lookup(name).(*types.Const).Val()
+		if !seen[value.ExactString()] {
+			seen[value.ExactString()] = true
+			schema.Enum = append(schema.Enum, constantValue(value))
+		}
+	}
+	return schema
+}
+
+// isEnum reports whether the type is an enum: an integer
+// or a string type with the typed constants.
+func isEnum(decl parser.TypeDecl) bool {
+	basic, ok := decl.Underlying.(*types.Basic)
+	return ok && basic.Info()&(types.IsInteger|types.IsString) != 0 && len(decl.Constants) > 0
+}
+
+// marshalerSchema returns the schema of the type marshaling itself:
+// time.Time is the date-time string, text marshalers are strings,
+// the output of JSON marshalers is unknown, so any value is allowed.
+func marshalerSchema(named *types.Named) (*openAPISchema, bool) {
+	obj := named.Obj()
+	if obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+		return &openAPISchema{Type: "string", Format: "date-time"}, true
+	}
+
+	methods := types.NewMethodSet(types.NewPointer(named))
+	switch {
+	case methods.Lookup(nil, "MarshalJSON") != nil:
+		return &openAPISchema{}, true
+	case methods.Lookup(nil, "MarshalText") != nil && obj.Name() == "UUID":
+		return &openAPISchema{Type: "string", Format: "uuid"}, true
+	case methods.Lookup(nil, "MarshalText") != nil:
+		return &openAPISchema{Type: "string"}, true
+	}
+	return nil, false
+}
+
+// basicSchema returns the schema of the basic type with the format of its size.
+func basicSchema(basic *types.Basic) *openAPISchema {
+	var schema *openAPISchema
+	switch basic.Kind() {
+	case types.Bool:
+		schema = &openAPISchema{Type: "boolean"}
+	case types.Int8, types.Int16, types.Int32, types.Uint8, types.Uint16:
+		schema = &openAPISchema{Type: "integer", Format: "int32"}
+	case types.Int, types.Int64, types.Uint, types.Uint32, types.Uint64, types.Uintptr:
+		schema = &openAPISchema{Type: "integer", Format: "int64"}
+	case types.Float32:
+		schema = &openAPISchema{Type: "number", Format: "float"}
+	case types.Float64:
+		schema = &openAPISchema{Type: "number", Format: "double"}
+	case types.String:
+		schema = &openAPISchema{Type: "string"}
+	default:
+		return &openAPISchema{}
+	}
+
+	if basic.Info()&types.IsUnsigned != 0 {
+		zero := 0.0
+		schema.Minimum = &zero
+	}
+	return schema
+}
+
+// constantValue returns the value of the constant to be written into the document.
+func constantValue(value constant.Value) interface{} {
+	switch value.Kind() {
+	case constant.String:
+		return constant.StringVal(value)
+	case constant.Int:
+		if v, exact := constant.Int64Val(value); exact {
+			return v
+		}
+	}
+	return value.ExactString()
+}
+
+// applyRules adds the constraints of the validation rules to the schema,
+// invalid rules and the ones, which can't be described, are skipped.
+// References can't be constrained, other attributes of them are ignored.
+func applyRules(schema *openAPISchema, rules []templates.ValidateRule) {
+	if schema.Ref != "" {
+		return
+	}
+
+	for _, rule := range rules {
+		switch rule.Name {
+		case "length":
+			min, max := lengthBound(rule.Args[0]), lengthBound(rule.Args[1])
+			if schema.Type == "array" {
+				schema.MinItems, schema.MaxItems = min, max
+			} else {
+				schema.MinLength, schema.MaxLength = min, max
+			}
+		case "min", "max":
+			value, err := strconv.ParseFloat(rule.Args[0], 64)
+			if err != nil {
+				continue
+			}
+			if rule.Name == "min" {
+				schema.Minimum = &value
+			} else {
+				schema.Maximum = &value
+			}
+		case "in":
+			schema.Enum = nil
+			for _, item := range rule.Args {
+				schema.Enum = append(schema.Enum, enumValue(schema.Type, item))
+			}
+		case "match":
+			schema.Pattern = rule.Args[0]
+		default:
+			if rule.Is != nil && rule.Is.Format != "" {
+				schema.Format = rule.Is.Format
+			}
+			if rule.Is != nil && rule.Is.Pattern != "" {
+				schema.Pattern = rule.Is.Pattern
+			}
+		}
+	}
+}
+
+// lengthBound returns the bound of the length rule, 0 is no limit.
+func lengthBound(bound string) *uint64 {
+	value, err := strconv.ParseUint(bound, 10, 64)
+	if err != nil || value == 0 {
+		return nil
+	}
+	return &value
+}
+
+// enumValue returns the value of the `in` rule of the schema type.
+func enumValue(schemaType, value string) interface{} {
+	switch schemaType {
+	case "integer":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// jsonTag splits the `json` tag into the name and the options.
+func jsonTag(tag string) (string, map[string]bool) {
+	parts := strings.Split(tag, ",")
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+
+	options := map[string]bool{}
+	for _, option := range parts[1:] {
+		options[option] = true
+	}
+	return parts[0], options
+}
+
+func derefType(typ types.Type) types.Type {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		return ptr.Elem()
+	}
+	return typ
+}
+
+func hasProperty(schema *openAPISchema, name interface{}) bool {
+	for _, item := range schema.Properties {
+		if item.Key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSchemas writes the schemas into the components of the OpenAPI document placed
+// in the path. Other parts of the existing document are kept in their order,
+// the new document is created with the title of the package. The document is
+// decoded and encoded again, so its comments and formatting are not kept.
+func mergeSchemas(path, title string, schemas map[string]*openAPISchema) ([]byte, error) {
+	doc := yaml.MapSlice{
+		{Key: "openapi", Value: openAPIVersion},
+		{Key: "info", Value: yaml.MapSlice{{Key: "title", Value: title}, {Key: "version", Value: "1.0.0"}}},
+		{Key: "paths", Value: yaml.MapSlice{}},
+	}
+	src, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		doc = nil
+		if err := yaml.Unmarshal(src, &doc); err != nil {
+			return nil, fmt.Errorf("reading %s: %v", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	components, err := yamlMap(doc, "components")
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", path, err)
+	}
+	existing, err := yamlMap(components, "schemas")
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: components: %v", path, err)
+	}
+
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		existing = setYAMLItem(existing, name, schemas[name])
+	}
+	components = setYAMLItem(components, "schemas", existing)
+	doc = setYAMLItem(doc, "components", components)
+
+	return yaml.Marshal(doc)
+}
+
+// yamlMap returns the mapping of the key or nil, if the key isn't set.
+func yamlMap(m yaml.MapSlice, key string) (yaml.MapSlice, error) {
+	for _, item := range m {
+		if item.Key != key || item.Value == nil {
+			continue
+		}
+		value, ok := item.Value.(yaml.MapSlice)
+		if !ok {
+			return nil, fmt.Errorf("%s: should be a mapping", key)
+		}
+		return value, nil
+	}
+	return nil, nil
+}
+
+// setYAMLItem sets the value of the key keeping the order of the keys, new keys are appended.
+func setYAMLItem(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
@@ -0,0 +1,169 @@
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+func TestGenerateOpenAPI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := `package models
+
+import "time"
+
+type Color int
+
+const (
+	ColorDarkRed Color = iota + 1
+	ColorGreen
+)
+
+type Level string
+
+const (
+	LevelLow  Level = "low"
+	LevelHigh Level = "high"
+)
+
+type Base struct {
+	ID int64 ` + "`json:\"id\"`" + `
+}
+
+type Box struct {
+	Base
+	Name    string     ` + "`json:\"name\" validate:\"required,length=3:64\"`" + `
+	Color   Color      ` + "`json:\"color\"`" + `
+	Levels  []Level    ` + "`json:\"levels,omitempty\" validate:\"length=1:\"`" + `
+	Email   *string    ` + "`json:\"email\" validate:\"email\"`" + `
+	Size    uint8      ` + "`json:\"size\" validate:\"max=10\"`" + `
+	Next    *Box       ` + "`json:\"next,omitempty\"`" + `
+	Created time.Time  ` + "`json:\"created\"`" + `
+	Skip    string     ` + "`json:\"-\"`" + `
+	hidden  string
+}
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644))
+
+	// the strings of the enum are taken from the generated code
+	enums := configs.EnumsConfig{
+		BaseConfig: configs.BaseConfig{Dir: dir, Types: []string{"Color"}, NoCache: true},
+		EnumOptions: configs.EnumOptions{
+			TransformRule:   templates.TransformRuleSnake,
+			Outputs:         templates.DefaultEnumOutputs,
+			DuplicatePolicy: parser.DuplicateFirst,
+			Lookup:          templates.LookupMap,
+		},
+	}
+	enumFiles, err := GenerateEnums(enums)
+	require.NoError(t, err)
+	require.NoError(t, enumFiles.Apply(false))
+
+	doc := `openapi: 3.0.3
+info:
+  title: Boxes
+  version: 2.0.0
+paths:
+  /boxes:
+    get: {}
+components:
+  schemas:
+    Manual:
+      type: string
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, configs.DefaultOpenAPIName), []byte(doc), 0644))
+
+	config := configs.OpenAPIConfig{}
+	config.Dir = dir
+	config.Types = []string{"Box"}
+	require.NoError(t, config.Validate())
+
+	files, err := GenerateOpenAPI(config)
+	require.NoError(t, err)
+	path := filepath.Join(dir, configs.DefaultOpenAPIName)
+	assert.Equal(t, []string{path}, files.Paths())
+	assert.Equal(t, doc+`    Box:
+      type: object
+      properties:
+        name:
+          type: string
+          minLength: 3
+          maxLength: 64
+        color:
+          $ref: '#/components/schemas/Color'
+        levels:
+          type: array
+          minItems: 1
+          items:
+            $ref: '#/components/schemas/Level'
+        email:
+          type: string
+          format: email
+        size:
+          type: integer
+          format: int32
+          minimum: 0
+          maximum: 10
+        next:
+          $ref: '#/components/schemas/Box'
+        created:
+          type: string
+          format: date-time
+        id:
+          type: integer
+          format: int64
+      required:
+      - name
+      - color
+      - size
+      - created
+      - id
+    Color:
+      type: string
+      enum:
+      - dark_red
+      - green
+    Level:
+      type: string
+      enum:
+      - low
+      - high
+`, string(files[path]))
+}
+
+func TestGenerateOpenAPI_selfEmbedding(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := "package models\n\ntype Node struct {\n\t*Node\n\tX int `json:\"x\"`\n}\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644))
+
+	config := configs.OpenAPIConfig{}
+	config.Dir = dir
+	config.Types = []string{"Node"}
+	require.NoError(t, config.Validate())
+
+	files, err := GenerateOpenAPI(config)
+	require.NoError(t, err)
+	assert.Contains(t, string(files[filepath.Join(dir, configs.DefaultOpenAPIName)]), `    Node:
+      type: object
+      properties:
+        x:
+          type: integer
+          format: int64
+      required:
+      - x
+`)
+}
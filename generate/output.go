@@ -0,0 +1,216 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Files is a set of the generated files: the output path and its content.
+// The nil content means that file must not exist, e.g. the output
+// of the previous run, which is not generated anymore.
+type Files map[string][]byte
+
+// Paths returns the sorted list of the file paths.
+func (files Files) Paths() []string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Merge adds all files of the other set.
+func (files Files) Merge(other Files) {
+	for path, src := range other {
+		files[path] = src
+	}
+}
+
+// Write writes the files to disk and removes the ones with nil content.
+// Files, which are up to date, are not touched.
+func (files Files) Write() error {
+	for _, path := range files.Paths() {
+		src := files[path]
+		if src == nil {
+			// don't care about is present file - we need to remove it.
+			os.Remove(path)
+			continue
+		}
+		if actual, err := ioutil.ReadFile(path); err == nil && bytes.Equal(actual, src) {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("creating output directory: %s", err)
+		}
+		if err := ioutil.WriteFile(path, src, 0644); err != nil {
+			return fmt.Errorf("writing output: %s", err)
+		}
+	}
+	return nil
+}
+
+// Check compares the files with the ones on disk without writing anything.
+// If they are different, the *DriftError with unified diff is returned.
+func (files Files) Check() error {
+	var diffs []string
+	for _, path := range files.Paths() {
+		diff, err := diffWithDisk(path, files[path])
+		if err != nil {
+			return err
+		}
+		if diff != "" {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	if len(diffs) > 0 {
+		return &DriftError{Diff: strings.Join(diffs, "")}
+	}
+	return nil
+}
+
+// Apply writes the files or, in the check mode, compares them with disk.
+func (files Files) Apply(check bool) error {
+	if check {
+		return files.Check()
+	}
+	return files.Write()
+}
+
+// DriftError is returned by the check mode,
+// when the generated files are out of date.
+type DriftError struct {
+	// Diff is a unified diff between the files on disk and the generated ones.
+	Diff string
+}
+
+func (err *DriftError) Error() string {
+	return "generated files are out of date:\n" + err.Diff
+}
+
+// diffWithDisk returns the unified diff between the file
+// on disk and the expected content, or empty string if they are equal.
+func diffWithDisk(path string, expected []byte) (string, error) {
+	actual, err := ioutil.ReadFile(path)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading %s: %v", path, err)
+	}
+
+	if !exists && expected == nil || exists && expected != nil && bytes.Equal(actual, expected) {
+		return "", nil
+	}
+
+	name := path
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			name = rel
+		}
+	}
+
+	fromFile, toFile := name, name
+	if !exists {
+		fromFile = "/dev/null"
+	}
+	if expected == nil {
+		toFile = "/dev/null"
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(actual),
+		B:        splitLines(expected),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("comparing %s: %v", path, err)
+	}
+	return diff, nil
+}
+
+func splitLines(src []byte) []string {
+	if len(src) == 0 {
+		return nil
+	}
+	return difflib.SplitLines(string(src))
+}
+
+// headerPrefix starts the header written into the generated files.
+const headerPrefix = "// generated by forge "
+
+// schemaHeaderPrefix starts the header of the GraphQL schemas generated by forge enum.
+const schemaHeaderPrefix = "# generated by forge "
+
+// codeHeaderPrefix starts the header of the Go convention written by the built-in model templates:
+// "// Code generated by forge <command>; DO NOT EDIT.".
+const codeHeaderPrefix = "// Code generated by forge "
+
+// isGenerated checks if the Go source is generated by forge,
+// the header is one of the comments before the package clause.
+func isGenerated(src []byte) bool {
+	return headerOf(src) != ""
+}
+
+// headerOf returns the forge header of the Go source without the trailing
+// "; DO NOT EDIT" or empty string, if the source isn't generated by forge.
+// The header of the Go convention and the one of the GraphQL schema are returned
+// in the same form: "// generated by forge <command>".
+func headerOf(src []byte) string {
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, headerPrefix) {
+			return strings.TrimSuffix(line, "; DO NOT EDIT")
+		}
+		if strings.HasPrefix(line, schemaHeaderPrefix) {
+			return headerPrefix + strings.TrimSuffix(strings.TrimPrefix(line, schemaHeaderPrefix), "; DO NOT EDIT")
+		}
+		if strings.HasPrefix(line, codeHeaderPrefix) {
+			return headerPrefix + strings.TrimSuffix(strings.TrimPrefix(line, codeHeaderPrefix), "; DO NOT EDIT.")
+		}
+		if strings.HasPrefix(line, "package ") {
+			return ""
+		}
+	}
+	return ""
+}
+
+// generatedFiles returns the sorted paths of the Go files in the dir generated by forge
+// except the test files.
+func generatedFiles(dir string) ([]string, error) {
+	return generatedByPattern(dir, "*.go")
+}
+
+// generatedByPattern returns the sorted paths of the files in the dir matching the pattern
+// and generated by forge, except the Go test files.
+func generatedByPattern(dir, pattern string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var result []string
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if isGenerated(src) {
+			result = append(result, path)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles_Check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	existing := filepath.Join(dir, "existing.go")
+	require.NoError(t, ioutil.WriteFile(existing, []byte("package a\n"), 0644))
+	missing := filepath.Join(dir, "missing.go")
+
+	tests := []struct {
+		name  string
+		files Files
+		diff  []string
+	}{
+		{name: "up to date", files: Files{existing: []byte("package a\n"), missing: nil}},
+		{
+			name:  "changed",
+			files: Files{existing: []byte("package b\n")},
+			diff:  []string{"-package a\n", "+package b\n"},
+		},
+		{
+			name:  "created",
+			files: Files{missing: []byte("package a\n")},
+			diff:  []string{"--- /dev/null\n", "+package a\n"},
+		},
+		{
+			name:  "removed",
+			files: Files{existing: nil},
+			diff:  []string{"+++ /dev/null\n", "-package a\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.files.Check()
+			if len(tt.diff) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.IsType(t, &DriftError{}, err)
+			for _, line := range tt.diff {
+				assert.Contains(t, err.(*DriftError).Diff, line)
+			}
+		})
+	}
+
+	_, err = os.Stat(missing)
+	assert.True(t, os.IsNotExist(err), "check must not write files")
+	_, err = os.Stat(existing)
+	assert.NoError(t, err, "check must not remove files")
+}
+
+func Test_headerOf(t *testing.T) {
+	for src, header := range map[string]string{
+		"// generated by forge enum --type Color; DO NOT EDIT\npackage colors\n":         "// generated by forge enum --type Color",
+		"// Code generated by forge model --type User; DO NOT EDIT.\n\npackage models\n": "// generated by forge model --type User",
+		"// Code generated by stringer; DO NOT EDIT.\n\npackage colors\n":                "",
+		"# generated by forge enum --outputs gql; DO NOT EDIT\n\nenum Color {\n}\n":      "// generated by forge enum --outputs gql",
+		"package colors\n\n// generated by forge enum --type Color; DO NOT EDIT\n":       "",
+	} {
+		assert.Equal(t, header, headerOf([]byte(src)), src)
+	}
+}
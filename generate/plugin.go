@@ -0,0 +1,314 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+// PluginPrefix is a prefix of the plugin executables,
+// `forge gen sql` runs the `forge-gen-sql` found in the PATH.
+const PluginPrefix = "forge-gen-"
+
+// PluginProtocolVersion is a version of the PluginRequest and PluginResponse,
+// it's changed with every incompatible change of them.
+const PluginProtocolVersion = 1
+
+// PluginRequest is passed to the plugin as JSON on stdin.
+type PluginRequest struct {
+	Version int `json:"version"`
+	// Command is the forge command to be written into the headers of generated files.
+	Command string            `json:"command"`
+	Package PluginPackage     `json:"package"`
+	Types   []PluginType      `json:"types"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// PluginPackage describes the package of the types.
+type PluginPackage struct {
+	Name string `json:"name"`
+	// Dir is an absolute path of the package, the plugin is run in it.
+	Dir string `json:"dir"`
+	// ImportPath is empty if it can't be resolved.
+	ImportPath string `json:"importPath,omitempty"`
+}
+
+// PluginType is an analysed type, only one of Model and Enum is set.
+type PluginType struct {
+	Name string `json:"name"`
+	// Model is set for the struct types.
+	Model *PluginModel `json:"model,omitempty"`
+	// Enum is set for the types with typed constants.
+	Enum *PluginEnum `json:"enum,omitempty"`
+}
+
+// PluginModel describes the struct type like the data of the model templates.
+type PluginModel struct {
+	Fields []PluginField `json:"fields"`
+}
+
+// PluginField is a field of the struct, see templates.Field.
+type PluginField struct {
+	Name string `json:"name"`
+	// Type is the type as it's written in the package of the struct, like `*time.Time`.
+	Type string `json:"type"`
+	// ImportPath is the import path of the package of the field type.
+	ImportPath string `json:"importPath,omitempty"`
+	// KeyImportPath is the import path of the package of the map key type.
+	KeyImportPath string `json:"keyImportPath,omitempty"`
+	// Basic is the basic underlying type, like `int64` of the enums.
+	Basic       string `json:"basic,omitempty"`
+	Validatable bool   `json:"validatable,omitempty"`
+	Pointer     bool   `json:"pointer,omitempty"`
+	Slice       bool   `json:"slice,omitempty"`
+	Map         bool   `json:"map,omitempty"`
+	Embedded    bool   `json:"embedded,omitempty"`
+	// Tags are the first values of the field tags, like `id` of `db:"id,pk"`.
+	Tags map[string]string `json:"tags,omitempty"`
+	// TagOptions are the sorted options of the field tags, like `pk` of `db:"id,pk"`.
+	TagOptions map[string][]string `json:"tagOptions,omitempty"`
+}
+
+// PluginEnum describes the type with typed constants like the data of the enum templates.
+type PluginEnum struct {
+	// Values are the constants in order of declaration.
+	Values []PluginValue `json:"values"`
+	// Declared are the methods and variables of the type, which are already declared
+	// in the package (like String), see parser.Package.ValuesOfType.
+	Declared []string `json:"declared,omitempty"`
+}
+
+// PluginValue is a constant of the enum type.
+type PluginValue struct {
+	Name string `json:"name"`
+	// Value is the exact integer value of the constant.
+	Value json.Number `json:"value"`
+	// String is the string of the value built by the transform option.
+	String string `json:"string"`
+	// Alias is true for the constant, which value is shared
+	// with another one chosen by the duplicates option.
+	Alias bool `json:"alias,omitempty"`
+}
+
+// PluginResponse is read from the plugin stdout as JSON.
+type PluginResponse struct {
+	Files []PluginFile `json:"files"`
+}
+
+// PluginFile is a file generated by the plugin.
+type PluginFile struct {
+	// Path is relative to the package directory and can't leave it.
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Plugin runs the external generator for the types listed in the config
+// and writes the files it returns.
+func Plugin(config configs.PluginConfig) error {
+	files, err := GeneratePlugin(config)
+	if err != nil {
+		return err
+	}
+	return files.Apply(config.Check)
+}
+
+// GeneratePlugin runs the external generator like Plugin, but returns
+// the files instead of writing them. The cache is never used,
+// because the plugin output depends on the executable.
+func GeneratePlugin(config configs.PluginConfig) (Files, error) {
+	executable, err := exec.LookPath(PluginPrefix + config.Name)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s is not found: %v", config.Name, err)
+	}
+
+	dir := "."
+	if config.Dir != "" {
+		dir = config.Dir
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine absolute filepath for requested path %s: %v", dir, err)
+	}
+
+	request, err := pluginRequest(dir, config)
+	if err != nil {
+		return nil, err
+	}
+	response, err := runPlugin(executable, dir, request)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %v", config.Name, err)
+	}
+
+	files, err := response.files(dir)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %v", config.Name, err)
+	}
+	return files, nil
+}
+
+// files returns the files of the response placed in the dir.
+func (response *PluginResponse) files(dir string) (Files, error) {
+	files := Files{}
+	for _, file := range response.Files {
+		path := filepath.Clean(filepath.FromSlash(file.Path))
+		if file.Path == "" || filepath.IsAbs(path) || path == ".." ||
+			strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("path %q is out of the package directory", file.Path)
+		}
+		files[filepath.Join(dir, path)] = []byte(file.Content)
+	}
+	return files, nil
+}
+
+// pluginRequest analyses the types of the package placed in the dir.
+func pluginRequest(dir string, config configs.PluginConfig) (*PluginRequest, error) {
+	var errs ErrorList
+	pkg, err := parser.ParsePackage(dir)
+	if err != nil {
+		errs.add(dir, "", "parsing package", err)
+		return nil, errs.Err()
+	}
+
+	command := config.Command
+	if command == "" {
+		command = "gen " + config.Name + " --type " + strings.Join(config.Types, ",")
+	}
+	// the import path is optional, the package may be outside of module and GOPATH
+	importPath, _ := parser.ImportPath(dir)
+
+	request := &PluginRequest{
+		Version: PluginProtocolVersion,
+		Command: command,
+		Package: PluginPackage{Name: pkg.Name, Dir: dir, ImportPath: importPath},
+		Options: config.Options,
+	}
+
+	for _, typeName := range config.Types {
+		pluginType, err := pluginTypeOf(pkg, typeName, config)
+		if err != nil {
+			errs.add(dir, typeName, "", err)
+			continue
+		}
+		request.Types = append(request.Types, pluginType)
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// pluginTypeOf analyses the type as a model, if it's a struct, or as an enum otherwise.
+func pluginTypeOf(pkg *parser.Package, typeName string, config configs.PluginConfig) (PluginType, error) {
+	if pkg.IsStruct(typeName) {
+		spec, err := pkg.FindStructureSpec(typeName)
+		if err != nil {
+			return PluginType{}, err
+		}
+		model, err := templates.FigureOut(spec)
+		if err != nil {
+			return PluginType{}, err
+		}
+		return PluginType{Name: typeName, Model: pluginModelOf(model)}, nil
+	}
+
+	values, excludeList, err := pkg.ValuesOfType(typeName, config.DuplicatePolicy)
+	if err != nil {
+		return PluginType{}, err
+	}
+	typeValues := config.TransformRule.TransformValues(typeName, values, config.AddTypePrefix)
+
+	enum := &PluginEnum{Values: make([]PluginValue, 0, len(values))}
+	for i, value := range values {
+		enum.Values = append(enum.Values, PluginValue{
+			Name:   value.Name,
+			Value:  json.Number(value.Value.ExactString()),
+			String: typeValues[i].Str,
+			Alias:  typeValues[i].Alias,
+		})
+	}
+	for name := range excludeList {
+		enum.Declared = append(enum.Declared, name)
+	}
+	sort.Strings(enum.Declared)
+	return PluginType{Name: typeName, Enum: enum}, nil
+}
+
+// pluginModelOf converts the data of the model templates into the protocol type.
+func pluginModelOf(model *templates.ModelSpec) *PluginModel {
+	result := &PluginModel{Fields: make([]PluginField, 0, len(model.Fields))}
+	for _, field := range model.Fields {
+		var tagOptions map[string][]string
+		for tag, options := range field.TagOpts {
+			if len(options) == 0 {
+				continue
+			}
+			if tagOptions == nil {
+				tagOptions = map[string][]string{}
+			}
+			for option := range options {
+				tagOptions[tag] = append(tagOptions[tag], option)
+			}
+			sort.Strings(tagOptions[tag])
+		}
+
+		result.Fields = append(result.Fields, PluginField{
+			Name:          field.Name,
+			Type:          field.FType,
+			ImportPath:    field.ImportPath,
+			KeyImportPath: field.KeyImportPath,
+			Basic:         field.Basic,
+			Validatable:   field.Validatable,
+			Pointer:       field.Pointer,
+			Slice:         field.Slice,
+			Map:           field.Map,
+			Embedded:      field.Embedded,
+			Tags:          field.Tags,
+			TagOptions:    tagOptions,
+		})
+	}
+	return result
+}
+
+// runPlugin passes the request to the plugin executable and reads its response.
+// Stderr of the plugin is logged or, if the plugin fails, returned in the error.
+func runPlugin(executable, dir string, request *PluginRequest) (*PluginResponse, error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(executable)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		if line != "" {
+			log.Printf("[INFO] %s: %s\n", filepath.Base(executable), line)
+		}
+	}
+
+	var response PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return nil, fmt.Errorf("decoding response: %v", err)
+	}
+	return &response, nil
+}
@@ -0,0 +1,95 @@
+package generate
+
+import (
+	"encoding/json"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lancer-kit/forge/configs"
+	"github.com/lancer-kit/forge/parser"
+	"github.com/lancer-kit/forge/templates"
+)
+
+func TestPluginResponse_files(t *testing.T) {
+	dir := filepath.FromSlash("/app/models")
+	cases := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "file", path: "user_sql.go", want: "/app/models/user_sql.go"},
+		{name: "subdirectory", path: "sql/../sql/user.sql", want: "/app/models/sql/user.sql"},
+		{name: "empty", path: "", wantErr: true},
+		{name: "absolute", path: "/etc/passwd", wantErr: true},
+		{name: "parent", path: "../user.go", wantErr: true},
+		{name: "parent only", path: "sql/../..", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			response := PluginResponse{Files: []PluginFile{{Path: tc.path, Content: "content"}}}
+			files, err := response.files(dir)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, Files{filepath.FromSlash(tc.want): []byte("content")}, files)
+		})
+	}
+}
+
+func Test_pluginTypeOf(t *testing.T) {
+	src := `package models
+
+type Role int
+
+const (
+	RoleAdmin Role = iota + 1
+	RoleRoot  Role = 1
+	RoleUser  Role = 2
+)
+
+func (r Role) String() string { return "" }
+
+type User struct {
+	ID    int64  ` + "`db:\"id,pk\" json:\"id\"`" + `
+	Roles []Role ` + "`json:\"roles\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "models.go", src, goparser.ParseComments)
+	require.NoError(t, err)
+	pkg, err := parser.ParseFiles(fset, []*ast.File{file})
+	require.NoError(t, err)
+
+	config := configs.PluginConfig{TransformRule: templates.TransformRuleSnake, DuplicatePolicy: parser.DuplicateFirst}
+	for typeName, want := range map[string]string{
+		"User": `{"name":"User","model":{"fields":[` +
+			`{"name":"ID","type":"int64","basic":"int64","tags":{"db":"id","json":"id"},"tagOptions":{"db":["pk"]}},` +
+			`{"name":"Roles","type":"[]Role","slice":true,"tags":{"json":"roles"}}]}}`,
+		"Role": `{"name":"Role","enum":{"values":[` +
+			`{"name":"RoleAdmin","value":1,"string":"admin"},` +
+			`{"name":"RoleRoot","value":1,"string":"root","alias":true},` +
+			`{"name":"RoleUser","value":2,"string":"user"}],"declared":["String"]}}`,
+	} {
+		pluginType, err := pluginTypeOf(pkg, typeName, config)
+		require.NoError(t, err)
+		raw, err := json.Marshal(pluginType)
+		require.NoError(t, err)
+		assert.JSONEq(t, want, string(raw), typeName)
+	}
+
+	// the strings keep the type prefix like the enum outputs
+	config.AddTypePrefix = true
+	pluginType, err := pluginTypeOf(pkg, "Role", config)
+	require.NoError(t, err)
+	assert.Equal(t, "role_admin", pluginType.Enum.Values[0].String)
+}
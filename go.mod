@@ -9,7 +9,9 @@ require (
 	github.com/fatih/camelcase v1.0.0
 	github.com/go-ozzo/ozzo-validation/v4 v4.2.1
 	github.com/pkg/errors v0.8.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/stretchr/testify v1.4.0
 	github.com/urfave/cli v1.20.0
 	golang.org/x/tools v0.0.0-20181026183834-f60e5f99f081
+	gopkg.in/yaml.v2 v2.2.2
 )
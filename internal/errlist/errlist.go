@@ -0,0 +1,32 @@
+// Package errlist contains the helpers shared by the lists of errors of forge.
+package errlist
+
+import (
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// SortByPosition stably sorts the slice of errors by their positions returned by pos,
+// the errors without position go first.
+func SortByPosition(list interface{}, pos func(i int) token.Position) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := pos(i), pos(j)
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// Join joins the messages of the n errors returned by err, one per line.
+func Join(n int, err func(i int) error) string {
+	msgs := make([]string, n)
+	for i := range msgs {
+		msgs[i] = err(i).Error()
+	}
+	return strings.Join(msgs, "\n")
+}
@@ -17,8 +17,17 @@ func main() {
 		cmd.EnumCmd(),
 		cmd.ModelCmd(),
 		cmd.BindataCmd(),
+		cmd.RunCmd(),
+		cmd.WatchCmd(),
+		cmd.GenCmd(),
+		cmd.InspectCmd(),
+		cmd.CleanCmd(),
+		cmd.MigrationCmd(),
+		cmd.OpenAPICmd(),
 		cmd.NewProjectCmd(),
 	}
 
+	// the header of the generated files is written by os.Args
+	os.Args = cmd.FixBoolArgs(app.Commands, os.Args)
 	_ = app.Run(os.Args)
 }
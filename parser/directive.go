@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EnumDirective marks the type as a target for the enum generation,
+// it can be followed by the space separated options:
+//
+//	//forge:enum transform=snake tprefix outputs=json,sql
+//	type ShirtSize int
+const EnumDirective = "//forge:enum"
+
+// recursiveSuffix is a suffix of the pattern,
+// which matches the directory and all its subdirectories.
+const recursiveSuffix = "/..."
+
+// Directive is a type declaration marked by the forge directive.
+type Directive struct {
+	TypeName string
+	// Options is a map of options passed with the directive,
+	// options without value (like `tprefix`) are set to "true".
+	Options map[string]string
+}
+
+// IsRecursive checks if the pattern matches packages recursively, like `./...`.
+func IsRecursive(pattern string) bool {
+	return pattern == "..." || strings.HasSuffix(filepath.ToSlash(pattern), recursiveSuffix)
+}
+
+// Dirs returns the absolute paths of the package directories matched by pattern.
+// The pattern is either a single directory or the directory with `/...` suffix,
+// in this case all its subdirectories with Go files are returned,
+// except the `vendor`, `testdata` and ones started with `.` or `_`.
+func Dirs(pattern string) ([]string, error) {
+	if !IsRecursive(pattern) {
+		dir, err := filepath.Abs(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine absolute filepath for requested path %s: %v", pattern, err)
+		}
+		return []string{dir}, nil
+	}
+
+	root := strings.TrimSuffix(strings.TrimSuffix(filepath.ToSlash(pattern), "..."), "/")
+	if root == "" {
+		root = "."
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine absolute filepath for requested path %s: %v", pattern, err)
+	}
+
+	var dirs []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		name := info.Name()
+		if path != root && (name == "vendor" || name == "testdata" ||
+			strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+
+		matches, err := filepath.Glob(filepath.Join(path, "*.go"))
+		if err != nil {
+			return err
+		}
+		for _, file := range matches {
+			if !strings.HasSuffix(file, "_test.go") {
+				dirs = append(dirs, path)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %v", root, err)
+	}
+
+	return dirs, nil
+}
+
+// FindDirectives inspects the Go files of the directory
+// and returns all type declarations marked with the directive.
+// Files are only parsed, so package may be not compilable at this moment.
+func FindDirectives(dir, directive string) ([]Directive, error) {
+	fset := token.NewFileSet()
+	notTest := func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}
+	pkgs, err := goparser.ParseDir(fset, dir, notTest, goparser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", dir, err)
+	}
+
+	var fileNames []string
+	byName := map[string]*ast.File{}
+	for _, pkg := range pkgs {
+		for name, file := range pkg.Files {
+			fileNames = append(fileNames, name)
+			byName[name] = file
+		}
+	}
+	// sort to keep result stable between invocations
+	sort.Strings(fileNames)
+
+	files := make([]*ast.File, len(fileNames))
+	for i, name := range fileNames {
+		files[i] = byName[name]
+	}
+	return DirectivesOf(files, directive), nil
+}
+
+// DirectivesOf returns all type declarations of the files marked with the directive.
+// Files must be parsed with comments.
+func DirectivesOf(files []*ast.File, directive string) []Directive {
+	var result []Directive
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec) // Guaranteed to succeed as this is TYPE.
+
+				doc := typeSpec.Doc
+				if doc == nil && len(genDecl.Specs) == 1 {
+					doc = genDecl.Doc
+				}
+
+				options, ok := parseDirective(doc, directive)
+				if !ok {
+					continue
+				}
+				result = append(result, Directive{
+					TypeName: typeSpec.Name.Name,
+					Options:  options,
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// parseDirective looks for the directive in the comments
+// and returns its options if the directive is found.
+func parseDirective(doc *ast.CommentGroup, directive string) (map[string]string, bool) {
+	if doc == nil {
+		return nil, false
+	}
+
+	for _, comment := range doc.List {
+		if !strings.HasPrefix(comment.Text, directive) {
+			continue
+		}
+
+		rest := comment.Text[len(directive):]
+		if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			// something like `//forge:enumeration`
+			continue
+		}
+
+		options := map[string]string{}
+		for _, option := range strings.Fields(rest) {
+			kv := strings.SplitN(option, "=", 2)
+			if len(kv) == 1 {
+				options[kv[0]] = "true"
+				continue
+			}
+			options[kv[0]] = kv[1]
+		}
+		return options, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"go/ast"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseDirective(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment string
+		options map[string]string
+		found   bool
+	}{
+		{name: "bare", comment: "//forge:enum", options: map[string]string{}, found: true},
+		{
+			name:    "with options",
+			comment: "//forge:enum transform=snake tprefix outputs=json,sql",
+			options: map[string]string{"transform": "snake", "tprefix": "true", "outputs": "json,sql"},
+			found:   true,
+		},
+		{name: "other directive", comment: "//forge:enumeration", found: false},
+		{name: "spaced", comment: "// forge:enum", found: false},
+		{name: "plain comment", comment: "// Color is a color.", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &ast.CommentGroup{List: []*ast.Comment{{Text: tt.comment}}}
+			options, found := parseDirective(doc, EnumDirective)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.options, options)
+		})
+	}
+}
+
+func TestIsRecursive(t *testing.T) {
+	assert.True(t, IsRecursive("./..."))
+	assert.True(t, IsRecursive("..."))
+	assert.True(t, IsRecursive("pkg/models/..."))
+	assert.False(t, IsRecursive("."))
+	assert.False(t, IsRecursive("./models"))
+}
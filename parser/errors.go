@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"sync"
+
+	"github.com/lancer-kit/forge/internal/errlist"
+)
+
+// Error is an error of the code analysis at the position in the source.
+type Error struct {
+	// Pos is invalid if the position is unknown.
+	Pos token.Position
+	Msg string
+}
+
+func (err *Error) Error() string {
+	if err.Pos.Filename != "" || err.Pos.IsValid() {
+		return err.Pos.String() + ": " + err.Msg
+	}
+	return err.Msg
+}
+
+// ErrorList is a list of the errors collected during the analysis.
+type ErrorList []*Error
+
+// Add adds the error at the position to the list.
+func (list *ErrorList) Add(pos token.Position, msg string) {
+	*list = append(*list, &Error{Pos: pos, Msg: msg})
+}
+
+// AddError adds the errors of the Go parser and type checker with their
+// positions, other errors are added without position.
+func (list *ErrorList) AddError(err error) {
+	switch err := err.(type) {
+	case *Error:
+		*list = append(*list, err)
+	case ErrorList:
+		*list = append(*list, err...)
+	case types.Error:
+		list.Add(err.Fset.Position(err.Pos), err.Msg)
+	case *scanner.Error:
+		list.Add(err.Pos, err.Msg)
+	case scanner.ErrorList:
+		for _, e := range err {
+			list.Add(e.Pos, e.Msg)
+		}
+	default:
+		list.Add(token.Position{}, err.Error())
+	}
+}
+
+// collector returns the function adding the errors to the list,
+// it is safe for concurrent use, like by the loader checking packages in parallel.
+func (list *ErrorList) collector() func(error) {
+	var mu sync.Mutex
+	return func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		list.AddError(err)
+	}
+}
+
+// Err returns the list sorted by positions or nil, if the list is empty.
+func (list ErrorList) Err() error {
+	if len(list) == 0 {
+		return nil
+	}
+	errlist.SortByPosition(list, func(i int) token.Position { return list[i].Pos })
+	return list
+}
+
+func (list ErrorList) Error() string {
+	return errlist.Join(len(list), func(i int) error { return list[i] })
+}
+
+// position returns the position of the node in the source.
+func (pkg *Package) position(pos token.Pos) token.Position {
+	return pkg.fset.Position(pos)
+}
+
+// typePosition returns the position of the package-level type declaration,
+// the result is false if the type isn't declared.
+func (pkg *Package) typePosition(typeName string) (token.Position, bool) {
+	for ident, obj := range pkg.defs {
+		if _, ok := obj.(*types.TypeName); ok && ident.Name == typeName && obj.Parent() == obj.Pkg().Scope() {
+			return pkg.position(ident.Pos()), true
+		}
+	}
+	return token.Position{}, false
+}
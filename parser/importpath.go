@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"go/build"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ImportPath returns the import path of the package placed in the directory.
+// It's resolved by the go.mod of the module containing the directory or,
+// if there is no module, relatively to the GOPATH.
+func ImportPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for root := dir; ; root = filepath.Dir(root) {
+		module, err := moduleOf(filepath.Join(root, "go.mod"))
+		if err != nil {
+			return "", err
+		}
+		if module != "" {
+			rel, err := filepath.Rel(root, dir)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(module, filepath.ToSlash(rel)), nil
+		}
+
+		if filepath.Dir(root) == root {
+			break
+		}
+	}
+
+	rel, err := filepath.Rel(filepath.Join(build.Default.GOPATH, "src"), dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("directory %s is neither in the Go module nor in the GOPATH", dir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// moduleOf returns the module path declared in the go.mod file,
+// or empty string if the file doesn't exist.
+func moduleOf(goMod string) (string, error) {
+	file, err := os.Open(goMod)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+			return unquoted, nil
+		}
+		return fields[1], nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading %s: %v", goMod, err)
+	}
+	return "", fmt.Errorf("%s: module path is not declared", goMod)
+}
+
+// PackageName returns the name of the package placed in the directory,
+// or empty string if the directory has no Go files.
+// Files listed in exclude (like previously generated outputs) are ignored.
+func PackageName(dir string, exclude ...string) (string, error) {
+	excluded := map[string]bool{}
+	for _, file := range exclude {
+		excluded[filepath.Clean(file)] = true
+	}
+
+	filter := func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && !excluded[filepath.Join(dir, info.Name())]
+	}
+	pkgs, err := goparser.ParseDir(token.NewFileSet(), dir, filter, goparser.PackageClauseOnly)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("parsing %s: %v", dir, err)
+	}
+
+	if len(pkgs) > 1 {
+		return "", fmt.Errorf("directory %s contains several packages", dir)
+	}
+	for name := range pkgs {
+		return name, nil
+	}
+	return "", nil
+}
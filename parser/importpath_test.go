@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportPath(t *testing.T) {
+	path, err := ImportPath(".")
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/lancer-kit/forge/parser", path)
+
+	path, err = ImportPath("../templates")
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/lancer-kit/forge/templates", path)
+}
+
+func TestPackageName(t *testing.T) {
+	name, err := PackageName(".")
+	require.NoError(t, err)
+	assert.Equal(t, "parser", name)
+
+	name, err = PackageName("./not-existing")
+	require.NoError(t, err)
+	assert.Equal(t, "", name)
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// TypeDecl is a named type declared in the package scope.
+type TypeDecl struct {
+	Name string
+	Pos  token.Position
+	// Type is the named type itself.
+	Type types.Type
+	// Underlying is the underlying type, like int or struct.
+	Underlying types.Type
+	// Constants are the names of the typed constants of the type in order of declaration.
+	Constants []string
+}
+
+// Types returns the types declared in the package scope in order of declaration.
+func (pkg *Package) Types() []TypeDecl {
+	var decls []TypeDecl
+	index := map[types.Object]int{}
+	for ident, obj := range pkg.defs {
+		if obj, ok := obj.(*types.TypeName); ok && obj.Parent() == obj.Pkg().Scope() && !obj.IsAlias() {
+			index[obj] = len(decls)
+			decls = append(decls, TypeDecl{
+				Name:       ident.Name,
+				Pos:        pkg.position(ident.Pos()),
+				Type:       obj.Type(),
+				Underlying: obj.Type().Underlying(),
+			})
+		}
+	}
+
+	var consts []*types.Const
+	for _, obj := range pkg.defs {
+		if obj, ok := obj.(*types.Const); ok && obj.Parent() == obj.Pkg().Scope() {
+			consts = append(consts, obj)
+		}
+	}
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+	for _, obj := range consts {
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			continue
+		}
+		if i, ok := index[named.Obj()]; ok {
+			decls[i].Constants = append(decls[i].Constants, obj.Name())
+		}
+	}
+
+	sort.Slice(decls, func(i, j int) bool {
+		a, b := decls[i].Pos, decls[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	return decls
+}
+
+// Method is a method declared for the type.
+type Method struct {
+	Name string
+	// Pointer is true for the pointer receiver.
+	Pointer bool
+	Pos     token.Position
+}
+
+// MethodsOfType returns the methods declared for the type in the package files.
+func (pkg *Package) MethodsOfType(typeName string) []Method {
+	var methods []Method
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			if method, ok := methodOf(typeName, funcDecl); ok {
+				method.Pos = pkg.position(funcDecl.Name.Pos())
+				methods = append(methods, method)
+			}
+		}
+	}
+	return methods
+}
+
+// methodOf returns the method without the position, if the function is declared for the type.
+// It's shared by MethodsOfType and the search of the methods skipped by the enum templates.
+func methodOf(typeName string, decl *ast.FuncDecl) (Method, bool) {
+	if decl.Name == nil {
+		return Method{}, false
+	}
+	receiver, pointer := receiverOf(decl)
+	if receiver == "" || receiver != typeName {
+		return Method{}, false
+	}
+	return Method{Name: decl.Name.Name, Pointer: pointer}, true
+}
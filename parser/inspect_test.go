@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackage_Types(t *testing.T) {
+	const src = `package colors
+
+type Color int
+
+const (
+	ColorRed Color = iota
+	ColorGreen
+	Max = 10
+)
+
+type User struct {
+	Name string
+}
+
+type Alias = User
+
+func (c *Color) String() string { return "" }
+
+func (u User) Validate() error { return nil }
+`
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "colors.go", src, 0)
+	require.NoError(t, err)
+	pkg, err := ParseFiles(fset, []*ast.File{file})
+	require.NoError(t, err)
+
+	decls := pkg.Types()
+	require.Len(t, decls, 2)
+	assert.Equal(t, "Color", decls[0].Name)
+	assert.Equal(t, "int", decls[0].Underlying.String())
+	assert.Equal(t, []string{"ColorRed", "ColorGreen"}, decls[0].Constants)
+	assert.Equal(t, "User", decls[1].Name)
+	assert.Empty(t, decls[1].Constants)
+
+	methods := pkg.MethodsOfType("Color")
+	require.Len(t, methods, 1)
+	assert.Equal(t, "String", methods[0].Name)
+	assert.True(t, methods[0].Pointer)
+	assert.Equal(t, 17, methods[0].Pos.Line)
+}
@@ -19,7 +19,11 @@ import (
 	"fmt"
 	"go/ast"
 	"go/build"
+	goparser "go/parser"
+	"go/token"
 	"go/types"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -39,94 +43,185 @@ var typeVariables = []string{
 //    value - show is receiver should be pointer or not.
 // map [methodName]shouldBePointer
 var typeMethods = map[string]bool{
-	"String":        false,
-	"Validate":      false,
-	"MarshalJSON":   false,
-	"UnmarshalJSON": true,
-	"Value":         false,
-	"Scan":          true,
+	"String":             false,
+	"Validate":           false,
+	"MarshalJSON":        false,
+	"UnmarshalJSON":      true,
+	"Value":              false,
+	"Scan":               true,
+	"MarshalGQL":         false,
+	"UnmarshalGQL":       true,
+	"MarshalXML":         false,
+	"UnmarshalXML":       true,
+	"MarshalXMLAttr":     false,
+	"UnmarshalXMLAttr":   true,
+	"MarshalBSONValue":   false,
+	"UnmarshalBSONValue": true,
 }
 
 // A Package contains all the information related to a parsed package.
 type Package struct {
 	Name  string
+	fset  *token.FileSet
 	files []*ast.File
+	types *types.Package
+	// enums are the names of the types marked by the enum directive.
+	enums map[string]bool
 
 	defs map[*ast.Ident]types.Object
 }
 
 // ParsePackage parses the package in the given directory and returns it.
-func ParsePackage(directory string) (*Package, error) {
-	relDir, err := filepath.Rel(filepath.Join(build.Default.GOPATH, "src"), directory)
+// Packages under GOPATH are imported by the import path,
+// others (like Go Modules) are imported relatively to the directory.
+// Files listed in exclude (like previously generated outputs) are ignored.
+func ParsePackage(directory string, exclude ...string) (*Package, error) {
+	var errs ErrorList
+	// comments are parsed to find the directives
+	conf := loader.Config{ParserMode: goparser.ParseComments, TypeChecker: types.Config{FakeImportC: true, Error: errs.collector()}}
+	// go/build resolves the imports of modules by the go command run in the Dir,
+	// so they are resolved by the module of the package rather than the working directory
+	ctxt := build.Default
+	ctxt.Dir = directory
+	conf.Build = &ctxt
+
+	if len(exclude) > 0 {
+		// only the files of the package are listed by the context excluding the files,
+		// the imports are resolved by the default one, since the custom context
+		// turns off the module support of go/build
+		bp, err := excludeFiles(exclude).ImportDir(directory, 0)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load package: %v", err)
+		}
+		importPath, err := ImportPath(directory)
+		if err != nil {
+			importPath = bp.Name
+		}
+
+		paths := make([]string, 0, len(bp.GoFiles)+len(bp.CgoFiles))
+		for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+			paths = append(paths, filepath.Join(bp.Dir, name))
+		}
+		conf.Cwd = directory
+		conf.CreateFromFilenames(importPath, paths...)
+	} else {
+		relDir, err := filepath.Rel(filepath.Join(build.Default.GOPATH, "src"), directory)
+		if err != nil || strings.HasPrefix(relDir, "..") {
+			conf.Cwd = directory
+			relDir = "."
+		}
+		conf.Import(relDir)
+	}
+
+	program, err := conf.Load()
 	if err != nil {
-		return nil, fmt.Errorf("provided directory not under GOPATH (%s): %v",
-			build.Default.GOPATH, err)
+		return nil, loadError(err, errs)
+	}
+
+	return packageOf(program), nil
+}
+
+// ParseFiles type-checks the already parsed files of one package and returns it.
+// Imports of the files are resolved relatively to the working directory.
+func ParseFiles(fset *token.FileSet, files []*ast.File) (*Package, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to parse")
 	}
 
-	conf := loader.Config{TypeChecker: types.Config{FakeImportC: true}}
-	conf.Import(relDir)
+	var errs ErrorList
+	conf := loader.Config{Fset: fset, TypeChecker: types.Config{FakeImportC: true, Error: errs.collector()}}
+	conf.CreateFromFiles(files[0].Name.Name, files...)
 	program, err := conf.Load()
 	if err != nil {
-		return nil, fmt.Errorf("couldn't load package: %v", err)
+		return nil, loadError(err, errs)
 	}
+	return packageOf(program), nil
+}
 
-	pkgInfo := program.Package(relDir)
+// loadError returns the positioned errors collected during the loading
+// or, if there are no such errors, the error of the loader.
+func loadError(err error, errs ErrorList) error {
+	if len(errs) > 0 {
+		return errs.Err()
+	}
+	return fmt.Errorf("couldn't load package: %v", err)
+}
+
+func packageOf(program *loader.Program) *Package {
+	pkgInfo := program.InitialPackages()[0]
+	enums := map[string]bool{}
+	for _, directive := range DirectivesOf(pkgInfo.Files, EnumDirective) {
+		enums[directive.TypeName] = true
+	}
 	return &Package{
 		Name:  pkgInfo.Pkg.Name(),
+		fset:  program.Fset,
 		files: pkgInfo.Files,
+		types: pkgInfo.Pkg,
+		enums: enums,
 		defs:  pkgInfo.Defs,
-	}, nil
+	}
 }
 
-// methodsOfTypeIn checks if a default methods is declared for the type,
-// if declared - add it to the ignore list, and the template for this
-// methods will NOT be added to the output file.
-func (pkg *Package) methodsOfTypeIn(typeName string, decl *ast.FuncDecl) map[string]bool {
-	if decl.Recv == nil || decl.Name == nil {
-		return nil
+// excludeFiles returns the build context, which doesn't list the given files
+// in directories. It can't resolve the imports of modules, so it's used
+// only to list the files of the package.
+func excludeFiles(paths []string) *build.Context {
+	excluded := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		excluded[filepath.Clean(path)] = true
 	}
 
-	var isTypeMethod, isPointerReceiver bool
-	for _, field := range decl.Recv.List {
-		if field.Type == nil {
-			continue
-		}
-
-		var ok bool
-		var ident *ast.Ident
-
-		switch i := field.Type.(type) {
-		case *ast.StarExpr:
-			ident, ok = i.X.(*ast.Ident)
-			isPointerReceiver = true
-		case *ast.Ident:
-			ident, ok = i, true
-		}
-
-		if !ok {
-			continue
+	ctxt := build.Default
+	ctxt.ReadDir = func(dir string) ([]os.FileInfo, error) {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
 		}
 
-		if ident.Name == typeName {
-			isTypeMethod = true
+		result := infos[:0]
+		for _, info := range infos {
+			if !excluded[filepath.Join(dir, info.Name())] {
+				result = append(result, info)
+			}
 		}
-
+		return result, nil
 	}
+	return &ctxt
+}
 
-	if !isTypeMethod {
+// methodsOfTypeIn checks if a default methods is declared for the type,
+// if declared - add it to the ignore list, and the template for this
+// methods will NOT be added to the output file.
+func (pkg *Package) methodsOfTypeIn(typeName string, decl *ast.FuncDecl) map[string]bool {
+	method, ok := methodOf(typeName, decl)
+	if !ok {
 		return nil
 	}
 
 	tmpls := map[string]bool{}
-	for mName, shouldBePointer := range typeMethods {
-		if !strings.Contains(decl.Name.Name, mName) {
-			continue
-		}
+	if shouldBePointer, ok := typeMethods[method.Name]; ok && shouldBePointer == method.Pointer {
+		tmpls[method.Name] = true
+	}
+	return tmpls
+}
 
-		if shouldBePointer == isPointerReceiver {
-			tmpls[mName] = true
-		}
+// receiverOf returns the name of the receiver type of the method
+// and reports whether the receiver is a pointer.
+func receiverOf(decl *ast.FuncDecl) (typeName string, pointer bool) {
+	if decl.Recv == nil {
+		return "", false
 	}
 
-	return tmpls
+	for _, field := range decl.Recv.List {
+		switch i := field.Type.(type) {
+		case *ast.StarExpr:
+			if ident, ok := i.X.(*ast.Ident); ok {
+				return ident.Name, true
+			}
+		case *ast.Ident:
+			return i.Name, false
+		}
+	}
+	return "", false
 }
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackage_methodsOfTypeIn(t *testing.T) {
+	src := `package sizes
+
+type Size int
+
+func (s Size) Stringify() string { return "" }
+func (s Size) MarshalXMLAttr(name xml.Name) (xml.Attr, error) { return xml.Attr{}, nil }
+func (s *Size) UnmarshalJSON(data []byte) error { return nil }
+func (s Size) Scan(src interface{}) error { return nil }
+func (o Other) String() string { return "" }
+`
+	file, err := goparser.ParseFile(token.NewFileSet(), "size.go", src, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	pkg := &Package{}
+	declared := map[string]bool{}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			for name := range pkg.methodsOfTypeIn("Size", fn) {
+				declared[name] = true
+			}
+		}
+	}
+	// the methods are matched by the exact name and the receiver kind,
+	// so MarshalXMLAttr doesn't hide MarshalXML and Stringify doesn't hide String
+	assert.Equal(t, map[string]bool{"MarshalXMLAttr": true, "UnmarshalJSON": true}, declared)
+}
+
+func TestParsePackage_outsideGOPATH(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	src := "package sizes\n\ntype Size int\n\nconst Small Size = 1\n"
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "size.go"), []byte(src), 0644)) {
+		return
+	}
+
+	// the directory isn't under GOPATH, so it's loaded relatively to itself
+	pkg, err := ParsePackage(dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "sizes", pkg.Name)
+}
+
+func TestParsePackage_moduleExclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	// the third-party module is resolved by the go.mod, not by GOPATH
+	sources := map[string]string{
+		"go.mod":           "module example.com/app\n\nrequire example.com/dep v0.0.0\n\nreplace example.com/dep => ./dep\n",
+		"dep/go.mod":       "module example.com/dep\n",
+		"dep/dep.go":       "package dep\n\ntype ID int\n",
+		"models/size.go":   "package models\n\nimport \"example.com/dep\"\n\ntype Size dep.ID\n\nconst Small Size = 1\n",
+		"models/output.go": "package models\n\nbroken\n",
+	}
+	for name, src := range sources {
+		path := filepath.Join(dir, name)
+		if !assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755)) ||
+			!assert.NoError(t, ioutil.WriteFile(path, []byte(src), 0644)) {
+			return
+		}
+	}
+
+	pkg, err := ParsePackage(filepath.Join(dir, "models"), filepath.Join(dir, "models", "output.go"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "models", pkg.Name)
+
+	// the package is loaded by the import path as well
+	if !assert.NoError(t, os.Remove(filepath.Join(dir, "models", "output.go"))) {
+		return
+	}
+	_, err = ParsePackage(filepath.Join(dir, "models"))
+	assert.NoError(t, err)
+}
@@ -1,86 +1,361 @@
 package parser
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 )
 
 type StructureSpec struct {
-	Name   string
+	Name string
+	// Pos is a position of the type declaration.
+	Pos    token.Position
 	Fields []string
+	// FTypes are the types of the fields as they're written
+	// in the package of the struct, like `*time.Time` or `[]Item`.
 	FTypes map[string]string
-	Tags   map[string]string
+	// Types describe the types of the fields in details.
+	Types map[string]FieldType
+	Tags  map[string]string
+	// FieldPos and TagPos are positions of the fields and their tags.
+	FieldPos map[string]token.Position
+	TagPos   map[string]token.Position
+	// Embedded are the specs of the embedded struct fields,
+	// which fields are promoted to the struct, see Flatten.
+	Embedded map[string]*StructureSpec
 }
 
-// StructureDef is inspect files for the declaration of target structure type ...TODO
-func (pkg *Package) FindStructureSpec(typeName string) (result *StructureSpec, err error) {
+// FieldType describes the type of the struct field.
+type FieldType struct {
+	// String is the type as it's written in the package of the struct.
+	String string
+	// Qualified is the type as it's written in other packages,
+	// like `[]models.Item` for `[]Item` of the models package.
+	Qualified string
+	// ImportPath is the import path of the named type of the field,
+	// or of its element type for pointers, slices, arrays and maps.
+	// It's empty for the predeclared types and the types of the struct package.
+	ImportPath string
+	// KeyImportPath is the import path of the named key type of the map field
+	// or of its element type, like `github.com/google/uuid` of `map[uuid.UUID]int`.
+	// It's empty for the predeclared types and the types of the struct package.
+	KeyImportPath string
+	// Basic is the name of the basic underlying type of the field or of its pointer
+	// element, like `int` for `type Size int`. It's empty for the other types.
+	Basic string
+	// Enum reports whether the type of the field or of its pointer element is a forge enum,
+	// it's marked by the enum directive or has the generated String and Value methods.
+	Enum bool
+	// Validatable reports whether the type of the field or of its element has
+	// the Validate method, like forge enums, or is a struct of the package,
+	// which can get the generated one.
+	Validatable bool
+	Pointer     bool
+	Slice       bool
+	Map         bool
+	Embedded    bool
+}
+
+func newStructureSpec(name string, pos token.Position) *StructureSpec {
+	return &StructureSpec{
+		Name:     name,
+		Pos:      pos,
+		FTypes:   map[string]string{},
+		Types:    map[string]FieldType{},
+		Tags:     map[string]string{},
+		FieldPos: map[string]token.Position{},
+		TagPos:   map[string]token.Position{},
+		Embedded: map[string]*StructureSpec{},
+	}
+}
+
+// FindStructureSpec describes the struct type declared in the package: its fields
+// in order of declaration with their types, tags and positions, and the specs
+// of the embedded structs. It fails if the type isn't declared or isn't a struct.
+func (pkg *Package) FindStructureSpec(typeName string) (*StructureSpec, error) {
+	return pkg.findStructureSpec(typeName, map[types.Object]bool{})
+}
+
+// IsStruct reports whether the type declared in the package is a struct.
+func (pkg *Package) IsStruct(typeName string) bool {
+	obj, ok := pkg.types.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return false
+	}
+	_, ok = obj.Type().Underlying().(*types.Struct)
+	return ok
+}
+
+func (pkg *Package) findStructureSpec(typeName string, visited map[types.Object]bool) (*StructureSpec, error) {
 	for _, file := range pkg.files {
-		if result != nil {
-			return
-		}
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.TYPE {
+				continue
+			}
 
-		ast.Inspect(file, func(node ast.Node) bool {
-			switch decl := node.(type) {
-			case *ast.GenDecl:
-				switch decl.Tok {
-				case token.TYPE:
-					for _, spec := range decl.Specs {
-						res, ok := parseStructSpec(typeName, spec)
-						if ok {
-							result = res
-							return false
-						}
-					}
-				default:
-					return true
+			for _, spec := range decl.Specs {
+				typeSpec := spec.(*ast.TypeSpec) // Guaranteed to succeed as this is TYPE.
+				if typeSpec.Name.Name != typeName {
+					continue
 				}
 
-			default:
-				return true
+				structSpec, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					return nil, &Error{Pos: pkg.position(typeSpec.Name.Pos()), Msg: fmt.Sprintf("type %s is not a struct", typeName)}
+				}
+				return pkg.parseStructSpec(typeSpec, structSpec, visited), nil
 			}
-
-			return true
-		})
+		}
 	}
+	return nil, &Error{Msg: fmt.Sprintf("type %s is not declared", typeName)}
+}
+
+func (pkg *Package) parseStructSpec(typeSpec *ast.TypeSpec, structSpec *ast.StructType, visited map[types.Object]bool) *StructureSpec {
+	typeName := typeSpec.Name.Name
+	obj := pkg.defs[typeSpec.Name]
+	structType := obj.Type().Underlying().(*types.Struct) // Guaranteed by the declaration.
+	// only the enclosing structs are tracked to stop the recursion
+	visited[obj] = true
+	defer delete(visited, obj)
+
+	res := newStructureSpec(typeName, pkg.fset.Position(typeSpec.Name.Pos()))
+
+	// fields of the struct type are listed in order of declaration,
+	// a multi-name declaration is expanded into the fields with the same tag
+	i := 0
+	for _, f := range structSpec.Fields.List {
+		positions := []token.Pos{f.Type.Pos()}
+		if len(f.Names) > 0 {
+			positions = positions[:0]
+			for _, name := range f.Names {
+				positions = append(positions, name.Pos())
+			}
+		}
 
-	return
+		for _, pos := range positions {
+			field := structType.Field(i)
+			i++
+
+			name := field.Name()
+			res.addField(field, pkg, pkg.fset.Position(pos))
+			if f.Tag != nil {
+				res.Tags[name] = f.Tag.Value
+				res.TagPos[name] = pkg.fset.Position(f.Tag.Pos())
+			}
+			if field.Embedded() {
+				if embedded := pkg.embeddedSpec(field.Type(), visited); embedded != nil {
+					res.Embedded[name] = embedded
+				}
+			}
+		}
+	}
+	return res
 }
 
-func parseStructSpec(typeName string, spec ast.Spec) (*StructureSpec, bool) {
-	typeSpec, ok := spec.(*ast.TypeSpec)
+// embeddedSpec returns the spec of the embedded struct type or nil,
+// if the type isn't a struct or it's one of the enclosing structs (e.g. embeds itself).
+func (pkg *Package) embeddedSpec(typ types.Type, visited map[types.Object]bool) *StructureSpec {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, ok := typ.(*types.Named)
 	if !ok {
-		return nil, false
+		return nil
+	}
+	structType, ok := named.Underlying().(*types.Struct)
+	if !ok || visited[named.Obj()] {
+		return nil
 	}
 
-	if typeSpec.Name.Name != typeName {
-		return nil, false
+	if named.Obj().Pkg() == pkg.types {
+		// the struct declared by another one, like `type Admin User`, has no fields
+		// in the declaration, so the field is kept embedded as is
+		res, _ := pkg.findStructureSpec(named.Obj().Name(), visited)
+		return res
 	}
 
-	structSpec, ok := typeSpec.Type.(*ast.StructType)
-	if !ok {
-		// todo: set error
-		return nil, false
+	// the struct of the other package is described by its type only
+	visited[named.Obj()] = true
+	defer delete(visited, named.Obj())
+
+	res := newStructureSpec(named.Obj().Name(), pkg.fset.Position(named.Obj().Pos()))
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		if !field.Exported() {
+			// unexported fields can't be accessed from the other package
+			continue
+		}
+
+		name := field.Name()
+		pos := pkg.fset.Position(field.Pos())
+		res.addField(field, pkg, pos)
+		if tag := structType.Tag(i); tag != "" {
+			res.Tags[name] = "`" + tag + "`"
+			res.TagPos[name] = pos
+		}
+		if field.Embedded() {
+			if embedded := pkg.embeddedSpec(field.Type(), visited); embedded != nil {
+				res.Embedded[name] = embedded
+			}
+		}
 	}
+	return res
+}
 
-	res := StructureSpec{
-		Name:   typeName,
-		FTypes: map[string]string{},
-		Tags:   map[string]string{},
+// addField adds the field with the type relative to the package of the struct.
+func (spec *StructureSpec) addField(field *types.Var, pkg *Package, pos token.Position) {
+	name := field.Name()
+	fieldType := pkg.fieldTypeOf(field.Type())
+	fieldType.Embedded = field.Embedded()
+
+	spec.Fields = append(spec.Fields, name)
+	spec.FTypes[name] = fieldType.String
+	spec.Types[name] = fieldType
+	spec.FieldPos[name] = pos
+}
+
+// fieldTypeOf describes the type in the package.
+func (p *Package) fieldTypeOf(typ types.Type) FieldType {
+	pkg := p.types
+	result := FieldType{
+		String: types.TypeString(typ, func(other *types.Package) string {
+			if other == pkg {
+				return ""
+			}
+			return other.Name()
+		}),
+		Qualified: types.TypeString(typ, func(other *types.Package) string {
+			return other.Name()
+		}),
 	}
 
-	for _, f := range structSpec.Fields.List {
-		ident, ok := f.Type.(*ast.Ident)
-		if !ok {
+	elem := typ
+	if ptr, ok := typ.(*types.Pointer); ok {
+		elem = ptr.Elem()
+	}
+	if basic, ok := elem.Underlying().(*types.Basic); ok {
+		result.Basic = basic.Name()
+		if named, ok := elem.(*types.Named); ok && basic.Info()&types.IsInteger != 0 {
+			result.Enum = p.isEnum(named)
+		}
+	}
+
+	switch typ.(type) {
+	case *types.Pointer:
+		result.Pointer = true
+	case *types.Slice:
+		result.Slice = true
+	case *types.Map:
+		result.Map = true
+	}
+
+	for {
+		switch t := typ.(type) {
+		case *types.Pointer:
+			typ = t.Elem()
+			continue
+		case *types.Slice:
+			typ = t.Elem()
+			continue
+		case *types.Array:
+			typ = t.Elem()
+			continue
+		case *types.Map:
+			if result.KeyImportPath == "" {
+				result.KeyImportPath = p.fieldTypeOf(t.Key()).ImportPath
+			}
+			typ = t.Elem()
 			continue
+		case *types.Named:
+			if other := t.Obj().Pkg(); other != nil && other != pkg {
+				result.ImportPath = other.Path()
+			}
+			result.Validatable = isValidatable(t, pkg)
 		}
+		return result
+	}
+}
 
-		// todo: add support of multi-name field declarations
-		name := f.Names[0].Name
+// isEnum reports whether the integer type is a forge enum: the type of the package
+// is marked by the enum directive or the type has the String and Value methods
+// like the generated ones, so it's stored by the names of its values.
+func (p *Package) isEnum(named *types.Named) bool {
+	if named.Obj().Pkg() == p.types && p.enums[named.Obj().Name()] {
+		return true
+	}
+	for _, name := range []string{"String", "Value"} {
+		obj, _, _ := types.LookupFieldOrMethod(named, false, named.Obj().Pkg(), name)
+		if _, ok := obj.(*types.Func); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidatable reports whether the type has the Validate method
+// or it's a struct declared in the package.
+func isValidatable(named *types.Named, pkg *types.Package) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), true, named.Obj().Pkg(), "Validate")
+	if _, ok := obj.(*types.Func); ok {
+		return true
+	}
+	_, ok := named.Underlying().(*types.Struct)
+	return ok && named.Obj().Pkg() == pkg
+}
 
-		res.Fields = append(res.Fields, name)
-		res.Tags[name] = f.Tag.Value
-		res.FTypes[name] = ident.Name
+// Flatten returns the spec, where the embedded struct fields are replaced
+// by the fields promoted from them. As in Go, the field of the shallower
+// depth hides the promoted ones, the conflicting fields of the same depth
+// are omitted. Embedded fields of non-struct types are kept.
+func (spec *StructureSpec) Flatten() *StructureSpec {
+	type entry struct {
+		spec  *StructureSpec
+		name  string
+		depth int
+	}
+
+	var entries []entry
+	var collect func(spec *StructureSpec, depth int)
+	collect = func(spec *StructureSpec, depth int) {
+		for _, name := range spec.Fields {
+			if embedded, ok := spec.Embedded[name]; ok {
+				collect(embedded, depth+1)
+				continue
+			}
+			entries = append(entries, entry{spec: spec, name: name, depth: depth})
+		}
+	}
+	collect(spec, 0)
 
+	depths := map[string]int{}
+	count := map[string]int{}
+	for _, e := range entries {
+		if depth, ok := depths[e.name]; ok && depth < e.depth {
+			continue
+		} else if ok && depth == e.depth {
+			count[e.name]++
+			continue
+		}
+		depths[e.name] = e.depth
+		count[e.name] = 1
+	}
+
+	res := newStructureSpec(spec.Name, spec.Pos)
+	for _, e := range entries {
+		if depths[e.name] != e.depth || count[e.name] > 1 {
+			continue
+		}
+
+		res.Fields = append(res.Fields, e.name)
+		res.FTypes[e.name] = e.spec.FTypes[e.name]
+		res.Types[e.name] = e.spec.Types[e.name]
+		res.FieldPos[e.name] = e.spec.FieldPos[e.name]
+		if tag, ok := e.spec.Tags[e.name]; ok {
+			res.Tags[e.name] = tag
+			res.TagPos[e.name] = e.spec.TagPos[e.name]
+		}
 	}
-	return &res, true
+	return res
 }
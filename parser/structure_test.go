@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackage_FindStructureSpec(t *testing.T) {
+	const src = `package models
+
+import "time"
+
+type Base struct {
+	ID        int64     ` + "`db:\"id\"`" + `
+	CreatedAt time.Time ` + "`db:\"created_at\"`" + `
+}
+
+type Item struct{}
+
+type Size int
+
+func (s Size) Validate() error { return nil }
+
+type User struct {
+	*Base
+	A, B  int            ` + "`db:\"ab\"`" + `
+	ID    string         ` + "`db:\"uid\"`" + `
+	Born  *time.Time     ` + "`db:\"born\"`" + `
+	Items []Item         ` + "`db:\"items\"`" + `
+	Attrs map[string]int ` + "`db:\"attrs\"`" + `
+	Size  *Size
+	Seen  map[time.Time][]Item
+}
+`
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "user.go", src, 0)
+	require.NoError(t, err)
+	pkg, err := ParseFiles(fset, []*ast.File{file})
+	require.NoError(t, err)
+
+	spec, err := pkg.FindStructureSpec("User")
+	require.NoError(t, err)
+	require.NotNil(t, spec)
+
+	assert.Equal(t, []string{"Base", "A", "B", "ID", "Born", "Items", "Attrs", "Size", "Seen"}, spec.Fields)
+	assert.Equal(t, FieldType{String: "*Base", Qualified: "*models.Base", Validatable: true, Pointer: true, Embedded: true}, spec.Types["Base"])
+	assert.Equal(t, FieldType{String: "int", Qualified: "int", Basic: "int"}, spec.Types["B"])
+	assert.Equal(t, "`db:\"ab\"`", spec.Tags["B"])
+	assert.Equal(t, 18, spec.FieldPos["B"].Line)
+	assert.Equal(t, FieldType{String: "*time.Time", Qualified: "*time.Time", ImportPath: "time", Pointer: true}, spec.Types["Born"])
+	assert.Equal(t, FieldType{String: "[]Item", Qualified: "[]models.Item", Validatable: true, Slice: true}, spec.Types["Items"])
+	assert.Equal(t, FieldType{String: "map[string]int", Qualified: "map[string]int", Map: true}, spec.Types["Attrs"])
+	assert.Equal(t, FieldType{String: "map[time.Time][]Item", Qualified: "map[time.Time][]models.Item", KeyImportPath: "time", Validatable: true, Map: true}, spec.Types["Seen"])
+	assert.Equal(t, FieldType{String: "*Size", Qualified: "*models.Size", Basic: "int", Validatable: true, Pointer: true}, spec.Types["Size"])
+	require.Contains(t, spec.Embedded, "Base")
+
+	_, err = pkg.FindStructureSpec("Admin")
+	assert.EqualError(t, err, "type Admin is not declared")
+	_, err = pkg.FindStructureSpec("Size")
+	assert.EqualError(t, err, "user.go:12:6: type Size is not a struct")
+	assert.True(t, pkg.IsStruct("User"))
+	assert.False(t, pkg.IsStruct("Size"))
+
+	flat := spec.Flatten()
+	assert.Equal(t, []string{"CreatedAt", "A", "B", "ID", "Born", "Items", "Attrs", "Size", "Seen"}, flat.Fields)
+	assert.Equal(t, "`db:\"uid\"`", flat.Tags["ID"])
+	assert.Equal(t, "time.Time", flat.FTypes["CreatedAt"])
+}
@@ -6,15 +6,52 @@ import (
 	"go/constant"
 	"go/token"
 	"go/types"
-	"log"
+	"strconv"
 	"strings"
 )
 
+// Value is a constant declared for the enum type.
+type Value struct {
+	Name string
+	// Pos is a position of the constant declaration.
+	Pos token.Position
+	// Value is the exact value of the constant.
+	Value constant.Value
+	// AliasOf is a name of the constant with the same value,
+	// which is chosen by DuplicatePolicy as the canonical one.
+	// It is empty for the canonical constants.
+	AliasOf string
+}
+
+// DuplicatePolicy defines how to deal with constants sharing the same value.
+type DuplicatePolicy string
+
+const (
+	// DuplicateError rejects the type with duplicate values.
+	DuplicateError DuplicatePolicy = "error"
+	// DuplicateFirst makes the first declared constant canonical.
+	DuplicateFirst DuplicatePolicy = "first"
+	// DuplicateLast makes the last declared constant canonical.
+	DuplicateLast DuplicatePolicy = "last"
+)
+
+// Validate is an implementation of Validatable interface.
+func (policy DuplicatePolicy) Validate() error {
+	switch policy {
+	case DuplicateError, DuplicateFirst, DuplicateLast:
+		return nil
+	default:
+		return fmt.Errorf("DuplicatePolicy(%s) is invalid", policy)
+	}
+}
+
 // ValuesOfType is inspect files for constant value, default variable and methods for type,
 // return a list of the constant values, and map of templates which must be ignored,
-// because they have already been declared.
-func (pkg *Package) ValuesOfType(typeName string) ([]string, map[string]bool, error) {
-	var values, inspectErrs []string
+// because they have already been declared. Constants with duplicate values
+// are resolved according to the policy.
+func (pkg *Package) ValuesOfType(typeName string, policy DuplicatePolicy) ([]Value, map[string]bool, error) {
+	var values []Value
+	var inspectErrs ErrorList
 	tmplsToExclude := map[string]bool{}
 
 	for _, file := range pkg.files {
@@ -26,7 +63,7 @@ func (pkg *Package) ValuesOfType(typeName string) ([]string, map[string]bool, er
 					vs, err := pkg.constOfTypeIn(typeName, decl)
 					values = append(values, vs...)
 					if err != nil {
-						inspectErrs = append(inspectErrs, err.Error())
+						inspectErrs.AddError(err)
 					}
 
 				case token.VAR:
@@ -52,51 +89,132 @@ func (pkg *Package) ValuesOfType(typeName string) ([]string, map[string]bool, er
 	}
 
 	if len(inspectErrs) > 0 {
-		return nil, nil, fmt.Errorf("inspecting code:\n\t%v", strings.Join(inspectErrs, "\n\t"))
+		return nil, nil, inspectErrs.Err()
 	}
 	if len(values) == 0 {
-		return nil, nil, fmt.Errorf("no values defined for type %s", typeName)
+		pos, ok := pkg.typePosition(typeName)
+		if !ok {
+			return nil, nil, &Error{Msg: fmt.Sprintf("type %s is not declared", typeName)}
+		}
+		return nil, nil, &Error{Pos: pos, Msg: fmt.Sprintf("no values defined for type %s", typeName)}
+	}
+
+	if err := resolveDuplicates(values, policy); err != nil {
+		return nil, nil, err
 	}
 
 	return values, tmplsToExclude, nil
 }
 
-// constOfTypeIn checks if a constant values is declared
-// for the type and add it to the result list.
-func (pkg *Package) constOfTypeIn(typeName string, decl *ast.GenDecl) ([]string, error) {
-	var values []string
+// resolveDuplicates groups the constants by their values
+// and marks all of them, except the canonical one, as aliases.
+func resolveDuplicates(values []Value, policy DuplicatePolicy) error {
+	var order []string
+	groups := map[string][]int{}
+	for i, v := range values {
+		key := v.Value.ExactString()
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
 
-	// The name of the type of the constants we are declaring.
-	// Can change if this is a multi-element declaration.
-	typ := ""
-	// Loop over the elements of the declaration. Each element is a ValueSpec:
-	// a list of names possibly followed by a type, possibly followed by values.
-	// If the type and value are both missing, we carry down the type (and value,
-	// but the "go/types" package takes care of that).
-	for _, spec := range decl.Specs {
-		vspec := spec.(*ast.ValueSpec) // Guaranteed to succeed as this is CONST.
-		if vspec.Type == nil && len(vspec.Values) > 0 {
-			// "X = 1". With no type but a value, the constant is untyped.
-			// Skip this vspec and reset the remembered type.
-			typ = ""
+	var dupErrs ErrorList
+	for _, key := range order {
+		group := groups[key]
+		if len(group) < 2 {
 			continue
 		}
-		if vspec.Type != nil {
-			// "X T". We have a type. Remember it.
-			ident, ok := vspec.Type.(*ast.Ident)
-			if !ok {
-				continue
+
+		var names []string
+		for _, i := range group {
+			names = append(names, values[i].Name)
+		}
+
+		canonical := group[0]
+		switch policy {
+		case DuplicateError:
+			dupErrs.Add(values[group[1]].Pos,
+				fmt.Sprintf("duplicate values: %s have the same value %s", strings.Join(names, ", "), key))
+			continue
+		case DuplicateLast:
+			canonical = group[len(group)-1]
+		}
+
+		for _, i := range group {
+			if i != canonical {
+				values[i].AliasOf = values[canonical].Name
 			}
-			typ = ident.Name
 		}
-		if typ != typeName {
-			// This is not the type we're looking for.
+	}
+
+	return dupErrs.Err()
+}
+
+// Layout describes the distribution of the canonical enum values.
+type Layout struct {
+	Min, Max int64
+	// Count is a number of the canonical values.
+	Count int
+	// Negative is a list of the constants with negative values.
+	Negative []string
+	// Dense is true if the values fill [Min, Max] range without gaps.
+	Dense bool
+	// Exact is false if some value does not fit into int64,
+	// in this case Min, Max and Dense are not reliable.
+	Exact bool
+}
+
+// Missing returns the number of values undefined within [Min, Max] range.
+func (l Layout) Missing() uint64 {
+	if l.Count == 0 {
+		return 0
+	}
+	// unsigned difference can't overflow even for the whole int64 range
+	return uint64(l.Max-l.Min) - uint64(l.Count-1)
+}
+
+// LayoutOf analyzes the canonical values of the enum.
+func LayoutOf(values []Value) Layout {
+	layout := Layout{Exact: true}
+	for _, v := range values {
+		if v.AliasOf != "" {
+			continue
+		}
+
+		val, exact := constant.Int64Val(v.Value)
+		if !exact {
+			layout.Exact = false
 			continue
 		}
+		if val < 0 {
+			layout.Negative = append(layout.Negative, v.Name)
+		}
+		if layout.Count == 0 || val < layout.Min {
+			layout.Min = val
+		}
+		if layout.Count == 0 || val > layout.Max {
+			layout.Max = val
+		}
+		layout.Count++
+	}
+
+	layout.Dense = layout.Exact && layout.Count > 0 && layout.Missing() == 0
+	return layout
+}
+
+// constOfTypeIn checks if a constant values is declared
+// for the type and add it to the result list.
+func (pkg *Package) constOfTypeIn(typeName string, decl *ast.GenDecl) ([]Value, error) {
+	var values []Value
+
+	// Loop over the elements of the declaration. Each element is a ValueSpec:
+	// a list of names possibly followed by a type, possibly followed by values.
+	// The type of constant may be omitted in source code ("X T = iota; Y" or
+	// alias "Y = X"), so we rely on "go/types", which takes care of that.
+	for _, spec := range decl.Specs {
+		vspec := spec.(*ast.ValueSpec) // Guaranteed to succeed as this is CONST.
 
-		// We now have a list of names (from one line of source code) all being
-		// declared with the desired type.
-		// Grab their names and actual values and store them in f.values.
 		for _, name := range vspec.Names {
 			if name.Name == "_" {
 				continue
@@ -106,17 +224,25 @@ func (pkg *Package) constOfTypeIn(typeName string, decl *ast.GenDecl) ([]string,
 			// types.Const, and extract its value.
 			obj, ok := pkg.defs[name]
 			if !ok {
-				return nil, fmt.Errorf("no value for constant %s", name)
+				return nil, &Error{Pos: pkg.position(name.Pos()), Msg: fmt.Sprintf("no value for constant %s", name)}
 			}
+
+			named, ok := obj.Type().(*types.Named)
+			if !ok || named.Obj().Name() != typeName || named.Obj().Pkg() != obj.Pkg() {
+				// This is not the type we're looking for.
+				continue
+			}
+
 			info := obj.Type().Underlying().(*types.Basic).Info()
 			if info&types.IsInteger == 0 {
-				return nil, fmt.Errorf("can't handle non-integer constant type %s", typ)
+				return nil, &Error{Pos: pkg.position(name.Pos()),
+					Msg: fmt.Sprintf("can't handle non-integer constant type %s", typeName)}
 			}
 			value := obj.(*types.Const).Val() // Guaranteed to succeed as this is CONST.
 			if value.Kind() != constant.Int {
-				log.Fatalf("can't happen: constant is not an integer %s", name)
+				return nil, &Error{Pos: pkg.position(name.Pos()), Msg: fmt.Sprintf("constant %s is not an integer", name)}
 			}
-			values = append(values, name.Name)
+			values = append(values, Value{Name: name.Name, Pos: pkg.position(name.Pos()), Value: value})
 		}
 	}
 	return values, nil
@@ -149,3 +275,59 @@ func (pkg *Package) varOfTypeIn(typeName string, decl *ast.GenDecl) map[string]b
 	}
 	return tmpls
 }
+
+// StringsOfType returns the strings of the enum values listed by the def<Type>ValueToName
+// map, which is generated by forge enum, in order of the map literal. The values of the map
+// built at runtime (like by the String method) are unknown, so nil is returned for them.
+// It reports false, if the map isn't declared.
+func (pkg *Package) StringsOfType(typeName string) ([]string, bool) {
+	varName := "def" + typeName + "ValueToName"
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				vspec := spec.(*ast.ValueSpec) // Guaranteed to succeed as this is VAR.
+				for i, name := range vspec.Names {
+					if name.Name != varName {
+						continue
+					}
+					if i >= len(vspec.Values) {
+						return nil, true
+					}
+					return stringsOfMap(vspec.Values[i]), true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// stringsOfMap returns the string literals of the values of the map literal
+// or nil, if any of the values isn't a string literal.
+func stringsOfMap(expr ast.Expr) []string {
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return nil
+		}
+		value, ok := kv.Value.(*ast.BasicLit)
+		if !ok || value.Kind != token.STRING {
+			return nil
+		}
+		str, err := strconv.Unquote(value.Value)
+		if err != nil {
+			return nil
+		}
+		result = append(result, str)
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"go/ast"
+	"go/constant"
+	goparser "go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pills() []Value {
+	return []Value{
+		{Name: "Placebo", Value: constant.MakeInt64(0)},
+		{Name: "Paracetamol", Value: constant.MakeInt64(1)},
+		{Name: "Acetaminophen", Value: constant.MakeInt64(1)},
+		{Name: "Ibuprofen", Value: constant.MakeInt64(2)},
+	}
+}
+
+func Test_resolveDuplicates(t *testing.T) {
+	values := pills()
+	assert.NoError(t, resolveDuplicates(values, DuplicateFirst))
+	assert.Equal(t, "", values[1].AliasOf)
+	assert.Equal(t, "Paracetamol", values[2].AliasOf)
+
+	values = pills()
+	assert.NoError(t, resolveDuplicates(values, DuplicateLast))
+	assert.Equal(t, "Acetaminophen", values[1].AliasOf)
+	assert.Equal(t, "", values[2].AliasOf)
+
+	values = pills()
+	assert.Error(t, resolveDuplicates(values, DuplicateError))
+}
+
+func TestLayoutOf(t *testing.T) {
+	values := pills()
+	assert.NoError(t, resolveDuplicates(values, DuplicateFirst))
+	layout := LayoutOf(values)
+	assert.True(t, layout.Dense)
+	assert.Equal(t, 3, layout.Count)
+	assert.Equal(t, uint64(0), layout.Missing())
+
+	values = append(values,
+		Value{Name: "Unknown", Value: constant.MakeInt64(-1)},
+		Value{Name: "Morphine", Value: constant.MakeInt64(10)},
+	)
+	layout = LayoutOf(values)
+	assert.False(t, layout.Dense)
+	assert.Equal(t, []string{"Unknown"}, layout.Negative)
+	assert.Equal(t, int64(-1), layout.Min)
+	assert.Equal(t, int64(10), layout.Max)
+	assert.Equal(t, uint64(7), layout.Missing())
+}
+
+func TestPackage_ValuesOfTypeErrors(t *testing.T) {
+	const src = `package colors
+
+type Color int
+
+const (
+	ColorRed Color = iota
+	ColorGreen
+	ColorLime = ColorGreen
+)
+
+type Empty int
+`
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "colors.go", src, 0)
+	require.NoError(t, err)
+	pkg, err := ParseFiles(fset, []*ast.File{file})
+	require.NoError(t, err)
+
+	cases := []struct {
+		typeName string
+		want     string
+	}{
+		{typeName: "Color", want: "colors.go:8:2: duplicate values: ColorGreen, ColorLime have the same value 1"},
+		{typeName: "Empty", want: "colors.go:11:6: no values defined for type Empty"},
+		{typeName: "Size", want: "type Size is not declared"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.typeName, func(t *testing.T) {
+			_, _, err := pkg.ValuesOfType(tc.typeName, DuplicateError)
+			assert.EqualError(t, err, tc.want)
+		})
+	}
+}
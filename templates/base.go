@@ -19,12 +19,19 @@ var EnumBase = []CodeTemplate{
 	{Name: "Invalid", Raw: typeError},
 	{Name: "NameToValue", Raw: nameToValueRaw},
 	{Name: "ValueToName", Raw: valueToNameRaw},
+	{Name: "Lookup", Raw: lookupRaw},
 	{Name: "String", Raw: stringRaw},
 	{Name: "Validate", Raw: validateRaw},
 	{Name: "MarshalJSON", Raw: marshalJSONRaw},
 	{Name: "UnmarshalJSON", Raw: unmarshalJSONRaw},
 	{Name: "Value", Raw: rowValueRaw},
 	{Name: "Scan", Raw: rowScanRaw},
+	{Name: "MarshalGQL", Raw: marshalGQLRaw, Imports: []string{"io"}},
+	{Name: "UnmarshalGQL", Raw: unmarshalGQLRaw},
+	{Name: "MarshalXML", Raw: marshalXMLRaw, Imports: []string{"encoding/xml"}},
+	{Name: "UnmarshalXML", Raw: unmarshalXMLRaw, Imports: []string{"encoding/xml"}},
+	{Name: "MarshalXMLAttr", Raw: marshalXMLAttrRaw, Imports: []string{"encoding/xml"}},
+	{Name: "UnmarshalXMLAttr", Raw: unmarshalXMLAttrRaw, Imports: []string{"encoding/xml"}},
 }
 
 var base = map[string]CodeTemplate{
@@ -40,12 +47,30 @@ var base = map[string]CodeTemplate{
 	"Scan":          {Name: "Scan", Raw: rowScanRaw},
 }
 
+// EnumOutputs maps the name of the optional output group
+// to the list of templates implementing it.
+var EnumOutputs = map[string][]string{
+	"json": {"MarshalJSON", "UnmarshalJSON"},
+	"sql":  {"Value", "Scan"},
+	"gql":  {"MarshalGQL", "UnmarshalGQL"},
+	"xml":  {"MarshalXML", "UnmarshalXML", "MarshalXMLAttr", "UnmarshalXMLAttr"},
+	"bson": {"MarshalBSONValue", "UnmarshalBSONValue"},
+}
+
+// DefaultEnumOutputs is a list of output groups generated by default.
+var DefaultEnumOutputs = []string{"json", "sql"}
+
 func init() {
 	FileBase.parse()
 
 	for i := range EnumBase {
 		EnumBase[i].parse()
 	}
+
+	BSONBase.parse()
+	for i := range EnumBSON {
+		EnumBSON[i].parse()
+	}
 }
 
 var (
@@ -58,7 +83,8 @@ import (
     "database/sql/driver"
     "encoding/json"
     "errors"
-    "fmt"
+    "fmt"{{range .Imports}}
+    {{if .}}"{{.}}"{{end}}{{end}}
 )
 
 func init() {
@@ -84,36 +110,67 @@ var def{{.TypeName}}NameToValue = map[string]{{.TypeName}} {
 
 	valueToNameRaw = `
 var def{{.TypeName}}ValueToName = map[{{.TypeName}}]string {
-        {{range .Values}}{{.Name}}: "{{.Str}}",
-        {{end}}
+        {{range .Values}}{{if not .Alias}}{{.Name}}: "{{.Str}}",
+        {{end}}{{end}}
     }
 `
 	stringRaw = `
 // String is generated so {{.TypeName}} satisfies fmt.Stringer.
 func (r {{.TypeName}}) String() string {
-    s, ok := def{{.TypeName}}ValueToName[r]
+    {{if eq .Lookup "index"}}i := int64(r){{.Shift}}
+    if i < 0 || i >= int64(len(_{{.TypeName}}_index)-1) {
+        return fmt.Sprintf("{{.TypeName}}(%d)", r)
+    }
+    return _{{.TypeName}}_name[_{{.TypeName}}_index[i]:_{{.TypeName}}_index[i+1]]
+    {{- else if eq .Lookup "switch"}}switch r {
+    {{range .Ordered}}case {{.Name}}:
+        return "{{.Str}}"
+    {{end}}}
+    return fmt.Sprintf("{{.TypeName}}(%d)", r)
+    {{- else}}s, ok := def{{.TypeName}}ValueToName[r]
     if !ok {
         return fmt.Sprintf("{{.TypeName}}(%d)", r)
     }
     return s
+    {{- end}}
 }
 `
 
 	validateRaw = `
 // Validate verifies that value is predefined for {{.TypeName}}.
 func (r {{.TypeName}}) Validate() error {
-    _, ok := def{{.TypeName}}ValueToName[r]
+    {{if eq .Lookup "index"}}if i := int64(r){{.Shift}}; i < 0 || i >= {{len .Ordered}} {
+        return Err{{.TypeName}}Invalid
+    }
+    return nil
+    {{- else if eq .Lookup "switch"}}switch r {
+    case {{range $i, $v := .Ordered}}{{if $i}}, {{end}}{{$v.Name}}{{end}}:
+        return nil
+    }
+    return Err{{.TypeName}}Invalid
+    {{- else}}_, ok := def{{.TypeName}}ValueToName[r]
     if !ok {
         return Err{{.TypeName}}Invalid
     }
     return nil
+    {{- end}}
 }
 `
 
 	marshalJSONRaw = `
 // MarshalJSON is generated so {{.TypeName}} satisfies json.Marshaler.
 func (r {{.TypeName}}) MarshalJSON() ([]byte, error) {
-    if s, ok := interface{}(r).(fmt.Stringer); ok {
+    {{if eq .Lookup "index"}}i := int64(r){{.Shift}}
+    if i < 0 || i >= {{len .Ordered}} {
+        return nil, fmt.Errorf("{{.TypeName}}(%d) is invalid value", r)
+    }
+    return append([]byte(nil), _{{.TypeName}}_json[i]...), nil
+    {{- else if eq .Lookup "switch"}}switch r {
+    {{range $i, $v := .Ordered}}case {{$v.Name}}:
+        return append([]byte(nil), _{{$.TypeName}}_json[{{$i}}]...), nil
+    {{end}}}
+    return nil, fmt.Errorf("{{.TypeName}}(%d) is invalid value", r)
+    {{- else}}if s, ok := interface{}(r).(fmt.Stringer); ok {
         return json.Marshal(s.String())
     }
     s, ok := def{{.TypeName}}ValueToName[r]
@@ -121,6 +178,7 @@ func (r {{.TypeName}}) MarshalJSON() ([]byte, error) {
         return nil, fmt.Errorf("{{.TypeName}}(%d) is invalid value", r)
     }
     return json.Marshal(s)
+    {{- end}}
 }
 `
 
@@ -180,5 +238,84 @@ func (r *{{.TypeName}}) Scan(src interface{}) error {
     }
     return errors.New("{{.TypeName}}: invalid type")
 }
+`
+
+	marshalGQLRaw = `
+// MarshalGQL is generated so {{.TypeName}} satisfies graphql.Marshaler.
+func (r {{.TypeName}}) MarshalGQL(w io.Writer) {
+    switch r {
+    {{range .Values}}{{if not .Alias}}case {{.Name}}:
+        _, _ = io.WriteString(w, ` + "`" + `"{{.GQL}}"` + "`" + `)
+    {{end}}{{end}}default:
+        _, _ = io.WriteString(w, "null")
+    }
+}
+`
+
+	unmarshalGQLRaw = `
+// UnmarshalGQL is generated so {{.TypeName}} satisfies graphql.Unmarshaler.
+func (r *{{.TypeName}}) UnmarshalGQL(v interface{}) error {
+    s, ok := v.(string)
+    if !ok {
+        return fmt.Errorf("{{.TypeName}}: should be a string, got %T", v)
+    }
+    switch s {
+    {{range .Values}}case "{{.GQL}}":
+        *r = {{.Name}}
+    {{end}}default:
+        return fmt.Errorf("{{.TypeName}}(%q) is invalid value", s)
+    }
+    return nil
+}
+`
+
+	marshalXMLRaw = `
+// MarshalXML is generated so {{.TypeName}} satisfies xml.Marshaler.
+func (r {{.TypeName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+    s, ok := def{{.TypeName}}ValueToName[r]
+    if !ok {
+        return fmt.Errorf("{{.TypeName}}(%d) is invalid value", r)
+    }
+    return e.EncodeElement(s, start)
+}
+`
+
+	unmarshalXMLRaw = `
+// UnmarshalXML is generated so {{.TypeName}} satisfies xml.Unmarshaler.
+func (r *{{.TypeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+    var s string
+    if err := d.DecodeElement(&s, &start); err != nil {
+        return fmt.Errorf("{{.TypeName}}: should be a string: %s", err)
+    }
+    v, ok := def{{.TypeName}}NameToValue[s]
+    if !ok {
+        return fmt.Errorf("{{.TypeName}}(%q) is invalid value", s)
+    }
+    *r = v
+    return nil
+}
+`
+
+	marshalXMLAttrRaw = `
+// MarshalXMLAttr is generated so {{.TypeName}} satisfies xml.MarshalerAttr.
+func (r {{.TypeName}}) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+    s, ok := def{{.TypeName}}ValueToName[r]
+    if !ok {
+        return xml.Attr{}, fmt.Errorf("{{.TypeName}}(%d) is invalid value", r)
+    }
+    return xml.Attr{Name: name, Value: s}, nil
+}
+`
+
+	unmarshalXMLAttrRaw = `
+// UnmarshalXMLAttr is generated so {{.TypeName}} satisfies xml.UnmarshalerAttr.
+func (r *{{.TypeName}}) UnmarshalXMLAttr(attr xml.Attr) error {
+    v, ok := def{{.TypeName}}NameToValue[attr.Value]
+    if !ok {
+        return fmt.Errorf("{{.TypeName}}(%q) is invalid value", attr.Value)
+    }
+    *r = v
+    return nil
+}
 `
 )
@@ -0,0 +1,65 @@
+package templates
+
+// BSONBase is a header of the file with BSON marshaling methods,
+// these files are placed under the build tag, so the mongo driver
+// becomes a dependency only of the builds with this tag.
+var BSONBase = CodeTemplate{Name: "bsonBase", Raw: bsonBaseRaw}
+
+var EnumBSON = []CodeTemplate{
+	{Name: "MarshalBSONValue", Raw: marshalBSONValueRaw, Imports: []string{
+		"fmt",
+		"go.mongodb.org/mongo-driver/bson/bsontype",
+		"go.mongodb.org/mongo-driver/x/bsonx/bsoncore",
+	}},
+	{Name: "UnmarshalBSONValue", Raw: unmarshalBSONValueRaw, Imports: []string{
+		"errors",
+		"fmt",
+		"go.mongodb.org/mongo-driver/bson/bsontype",
+		"go.mongodb.org/mongo-driver/x/bsonx/bsoncore",
+	}},
+}
+
+var (
+	bsonBaseRaw = `
+// generated by forge {{.Command}}; DO NOT EDIT
+
+//go:build {{.BuildTag}}
+// +build {{.BuildTag}}
+
+package {{.PackageName}}
+
+import ({{range .Imports}}
+    {{if .}}"{{.}}"{{end}}{{end}}
+)
+`
+
+	marshalBSONValueRaw = `
+// MarshalBSONValue is generated so {{.TypeName}} satisfies bsoncodec.ValueMarshaler.
+func (r {{.TypeName}}) MarshalBSONValue() (bsontype.Type, []byte, error) {
+    s, ok := def{{.TypeName}}ValueToName[r]
+    if !ok {
+        return 0, nil, fmt.Errorf("{{.TypeName}}(%d) is invalid value", r)
+    }
+    return bsontype.String, bsoncore.AppendString(nil, s), nil
+}
+`
+
+	unmarshalBSONValueRaw = `
+// UnmarshalBSONValue is generated so {{.TypeName}} satisfies bsoncodec.ValueUnmarshaler.
+func (r *{{.TypeName}}) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+    if t != bsontype.String {
+        return fmt.Errorf("{{.TypeName}}: should be a string, got %s", t)
+    }
+    s, _, ok := bsoncore.ReadString(data)
+    if !ok {
+        return errors.New("{{.TypeName}}: can't read string value")
+    }
+    v, ok := def{{.TypeName}}NameToValue[s]
+    if !ok {
+        return fmt.Errorf("{{.TypeName}}(%q) is invalid value", s)
+    }
+    *r = v
+    return nil
+}
+`
+)
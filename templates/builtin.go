@@ -0,0 +1,560 @@
+package templates
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// BuiltinPrefix starts the path of the built-in model template, like `builtin:armory-q`.
+const BuiltinPrefix = "builtin:"
+
+// builtinTemplates are the model templates shipped with forge by their names.
+var builtinTemplates = map[string]string{
+	"armory-q":          armoryQRaw,
+	"armory-q-accessor": armoryQAccessorRaw,
+	"chi-crud":          chiCrudRaw,
+	"validate":          validateModelRaw,
+}
+
+// IsBuiltin reports whether the path refers to the built-in model template.
+func IsBuiltin(templatePath string) bool {
+	return strings.HasPrefix(templatePath, BuiltinPrefix)
+}
+
+// ReadTemplate returns the source of the template file or the built-in template.
+func ReadTemplate(templatePath string) ([]byte, error) {
+	if !IsBuiltin(templatePath) {
+		return ioutil.ReadFile(templatePath)
+	}
+
+	name := strings.TrimPrefix(templatePath, BuiltinPrefix)
+	src, ok := builtinTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown built-in template %q, available: %s",
+			name, strings.Join(BuiltinNames(), ", "))
+	}
+	return []byte(src), nil
+}
+
+// BuiltinNames returns the sorted names of the built-in templates.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtinTemplates))
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// armoryQRaw is the squirrel query builder of the table on github.com/lancer-kit/armory/db.
+// Columns are the fields with `db` tags, the ones with the `pk` option are the primary key
+// used by Update and Delete. The single primary key column is generated by the database on Insert.
+// The accessor of the queries is generated by armoryQAccessorRaw.
+const armoryQRaw = `---
+output: "{{ snake .TypeName }}_q.go"
+---
+{{- $q := printf "%sQ" .TypeName -}}
+{{- $table := printf "Table%s" (plural .TypeName) -}}
+{{- $name := snake .TypeName | plural -}}
+{{- $type := .TypeRef -}}
+{{- $pk := "" -}}
+{{- $order := "" -}}
+{{- range .Fields -}}
+  {{- if hasTag . "db" -}}
+    {{- if not $order }}{{ $order = .Tags.db }}{{ end -}}
+    {{- if hasTagOpt . "db" "pk" -}}
+      {{- if $pk }}{{ $pk = printf "%s, %s" $pk .Tags.db }}{{ else }}{{ $pk = .Tags.db }}{{ $order = .Tags.db }}{{ end -}}
+    {{- end -}}
+  {{- end -}}
+{{- end -}}
+{{- $generated := and $pk (not (contains $pk ",")) -}}
+// Code generated by forge {{ .Command }}; DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lancer-kit/armory/db"
+)
+
+// {{ $table }} is the name of the table of {{ .TypeName }} records.
+const {{ $table }} = "{{ $name }}"
+
+// {{ $q }}I is the interface of the queries of the {{ $name }} table.
+type {{ $q }}I interface {
+	// Insert adds the new record{{ if $generated }} and sets its primary key generated by the database{{ end }}.
+	Insert(record *{{ $type }}) error
+{{- if $pk }}
+	// Update updates the record by its primary key.
+	Update(record *{{ $type }}) error
+	// Delete removes the record by its primary key.
+	Delete(record *{{ $type }}) error
+{{- end }}
+{{ range .Fields }}{{ if hasTag . "db" }}
+	// With{{ .Name }} filters the records by the {{ .Tags.db }} column.
+	With{{ .Name }}(value {{ .TypeRef }}) {{ $q }}I
+{{- end }}{{ end }}
+
+	// SetPage applies the pagination to the Get and Select.
+	SetPage(pq *db.PageQuery) {{ $q }}I
+	// Get returns the first record matching the filters or nil, if there is no one.
+	Get() (*{{ $type }}, error)
+	// Select returns the records matching the filters.
+	Select() ([]{{ $type }}, error)
+}
+
+// {{ $q }} implements the {{ $q }}I on the armory db.
+type {{ $q }} struct {
+	conn  *db.SQLConn
+	table db.Table
+}
+
+// New{{ $q }} returns the queries of the {{ $name }} table.
+func New{{ $q }}(conn *db.SQLConn) {{ $q }}I {
+	return &{{ $q }}{
+		conn: conn,
+		table: db.Table{
+			Name:     {{ $table }},
+			QBuilder: sq.Select("*").From({{ $table }}),
+		},
+	}
+}
+
+// Insert adds the new record{{ if $generated }} and sets its primary key generated by the database{{ end }}.
+func (q *{{ $q }}) Insert(record *{{ $type }}) error {
+	query := sq.Insert(q.table.Name).SetMap(map[string]interface{}{
+{{- range .Fields }}{{ if and (hasTag . "db") (not (and $generated (hasTagOpt . "db" "pk"))) }}
+		"{{ .Tags.db }}": record.{{ .Name }},
+{{- end }}{{ end }}
+	})
+{{- if $generated }}
+	return q.conn.Get(query.Suffix("RETURNING {{ $pk }}"), record)
+{{- else }}
+	return q.conn.Exec(query)
+{{- end }}
+}
+{{ if $pk }}
+// Update updates the record by its primary key.
+func (q *{{ $q }}) Update(record *{{ $type }}) error {
+	query := sq.Update(q.table.Name).SetMap(map[string]interface{}{
+{{- range .Fields }}{{ if and (hasTag . "db") (not (hasTagOpt . "db" "pk")) }}
+		"{{ .Tags.db }}": record.{{ .Name }},
+{{- end }}{{ end }}
+	}).Where(sq.Eq{
+{{- range .Fields }}{{ if hasTagOpt . "db" "pk" }}
+		"{{ .Tags.db }}": record.{{ .Name }},
+{{- end }}{{ end }}
+	})
+	return q.conn.Exec(query)
+}
+
+// Delete removes the record by its primary key.
+func (q *{{ $q }}) Delete(record *{{ $type }}) error {
+	query := sq.Delete(q.table.Name).Where(sq.Eq{
+{{- range .Fields }}{{ if hasTagOpt . "db" "pk" }}
+		"{{ .Tags.db }}": record.{{ .Name }},
+{{- end }}{{ end }}
+	})
+	return q.conn.Exec(query)
+}
+{{ end }}
+{{- range .Fields }}{{ if hasTag . "db" }}
+// With{{ .Name }} filters the records by the {{ .Tags.db }} column.
+func (q *{{ $q }}) With{{ .Name }}(value {{ .TypeRef }}) {{ $q }}I {
+	q.table.QBuilder = q.table.QBuilder.Where(sq.Eq{"{{ .Tags.db }}": value})
+	return q
+}
+{{ end }}{{ end }}
+// SetPage applies the pagination to the Get and Select.
+func (q *{{ $q }}) SetPage(pq *db.PageQuery) {{ $q }}I {
+	q.table.SetPage(pq)
+	return q
+}
+
+// Get returns the first record matching the filters or nil, if there is no one.
+func (q *{{ $q }}) Get() (*{{ $type }}, error) {
+	record := new({{ $type }})
+	err := q.conn.Get(q.selectQuery().Limit(1), record)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Select returns the records matching the filters.
+func (q *{{ $q }}) Select() ([]{{ $type }}, error) {
+	records := make([]{{ $type }}, 0)
+	err := q.conn.Select(q.selectQuery(), &records)
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// selectQuery returns the query of the records matching the filters with the pagination.
+// The pagination is applied to the copy of the table, so the filters are kept intact
+// and Get and Select can be called repeatedly.
+func (q *{{ $q }}) selectQuery() sq.SelectBuilder {
+	table := q.table
+	table.ApplyPage("{{ $order }}")
+	return table.QBuilder
+}
+`
+
+// armoryQAccessorRaw is the accessor of the queries generated by armoryQRaw on the Q
+// of the scaffolded models package. It's written into the package of the type regardless
+// of the dir flag, since methods can be declared only in the package of the Q.
+const armoryQAccessorRaw = `---
+output: "{{ snake .TypeName }}_q_accessor.go"
+package: .
+---
+{{- $q := printf "%sQ" .TypeName -}}
+// Code generated by forge {{ .Command }}; DO NOT EDIT.
+
+package {{ .Package }}
+
+// {{ plural .TypeName }} returns the queries of the {{ snake .TypeName | plural }} table,
+// add it into the QI interface to use them by the models.
+func (q *Q) {{ plural .TypeName }}() {{ $q }}I {
+	return New{{ $q }}(q.SQLConn)
+}
+`
+
+// validateModelRaw is the Validate method of the struct on ozzo-validation built by the `validate` tags,
+// see validationRules. Fields of the structs and enums are validated by their Validate methods.
+// It's written into the package of the type regardless of the dir flag like armoryQAccessorRaw.
+const validateModelRaw = `---
+output: "{{ snake .TypeName }}_validate.go"
+package: .
+---
+{{- $r := lowerFirst .TypeName | printf "%.1s" -}}
+{{- $prefix := lowerFirst .TypeName -}}
+{{- $patterns := false -}}
+{{- range .Fields }}{{ if validationPatterns . $prefix }}{{ $patterns = true }}{{ end }}{{ end -}}
+// Code generated by forge {{ .Command }}; DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+{{- if $patterns }}
+
+// The regular expressions of the match rules are compiled once.
+var (
+{{- range .Fields }}{{ range validationPatterns . $prefix }}
+	{{ .Name }} = {{ .Expr }}
+{{- end }}{{ end }}
+)
+{{- end }}
+
+// Validate validates the {{ .TypeName }} by the validate tags of its fields,
+// nested structs and enums are validated by their Validate methods.
+func ({{ $r }} {{ .TypeName }}) Validate() error {
+	return validation.ValidateStruct(&{{ $r }},
+{{- range .Fields }}{{ $rules := validationRules . $prefix }}{{ if or $rules .Validatable }}
+		validation.Field(&{{ $r }}.{{ .Name }}{{ range $rules }}, {{ . }}{{ end }}),
+{{- end }}{{ end }}
+	)
+}
+`
+
+// chiCrudRaw is the chi router of the CRUD handlers of the model on the queries generated by armoryQRaw
+// and their accessor generated by armoryQAccessorRaw,
+// it's written into the api package of the scaffolded project. The request DTO has the fields
+// with `json` tags except the primary key and is validated by the rules of their `validate` tags.
+// The list is filtered by the query parameters named by `json` tags of the columns.
+const chiCrudRaw = `---
+output: "{{ snake .TypeName }}_handlers.go"
+package: ../api
+---
+{{- $m := .Qualifier -}}
+{{- $type := .TypeRef -}}
+{{- $plural := plural .TypeName -}}
+{{- $name := snake .TypeName | plural -}}
+{{- $handlers := printf "%sHandlers" (lowerFirst .TypeName) -}}
+{{- $request := printf "%sRequest" (lowerFirst .TypeName) -}}
+{{- $patterns := false -}}
+{{- range .Fields -}}
+  {{- if and (hasTag . "json") (not (hasTagOpt . "db" "pk")) (validationPatterns . $request) }}{{ $patterns = true }}{{ end -}}
+{{- end -}}
+{{- $pk := "" -}}
+{{- $pkColumns := "" -}}
+{{- range .Fields -}}
+  {{- if hasTagOpt . "db" "pk" -}}
+    {{- if $pk }}{{ $pkColumns = printf "%s, %s" $pkColumns .Tags.db }}{{ else }}{{ $pkColumns = .Tags.db }}{{ end -}}
+    {{- $pk = . -}}
+  {{- end -}}
+{{- end -}}
+{{- if contains $pkColumns "," }}{{ $pk = "" }}{{ end -}}
+{{- $pkParam := "" -}}
+{{- if $pk }}{{ $pkParam = $pk.Tags.json | default (lowerFirst $pk.Name) }}{{ end -}}
+// Code generated by forge {{ .Command }}; DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/lancer-kit/armory/api/render"
+	"github.com/lancer-kit/armory/db"
+	"github.com/sirupsen/logrus"
+)
+
+// {{ lowerFirst $plural }}Router returns the CRUD handlers of the {{ $name }},
+// mount it in the getRouter:
+//
+//	r.Mount("/{{ kebab .TypeName | plural }}", {{ lowerFirst $plural }}Router(logger))
+func {{ lowerFirst $plural }}Router(logger *logrus.Entry) http.Handler {
+	h := {{ $handlers }}{logger: logger}
+
+	r := chi.NewRouter()
+	r.Get("/", h.list)
+	r.Post("/", h.create)
+{{- if $pk }}
+	r.Route("/{{ printf "{%s}" $pkParam }}", func(r chi.Router) {
+		r.Get("/", h.get)
+		r.Put("/", h.update)
+		r.Delete("/", h.delete)
+	})
+{{- end }}
+	return r
+}
+
+{{ if $patterns -}}
+// The regular expressions of the match rules of the {{ $request }} are compiled once.
+var (
+{{- range .Fields }}{{ if and (hasTag . "json") (not (hasTagOpt . "db" "pk")) }}{{ range validationPatterns . $request }}
+	{{ .Name }} = {{ .Expr }}
+{{- end }}{{ end }}{{ end }}
+)
+
+{{ end -}}
+// {{ $request }} is the body of the requests creating and updating the {{ .TypeName }}.
+type {{ $request }} struct {
+{{- range .Fields }}{{ if and (hasTag . "json") (not (hasTagOpt . "db" "pk")) }}
+	{{ .Name }} {{ .TypeRef }} ` + "`" + `json:"{{ .Tags.json }}{{ if hasTagOpt . "json" "omitempty" }},omitempty{{ end }}{{ if hasTagOpt . "json" "string" }},string{{ end }}"` + "`" + `
+{{- end }}{{ end }}
+}
+
+// Validate validates the request by the validate tags of the {{ .TypeName }} fields.
+func (r {{ $request }}) Validate() error {
+	return validation.ValidateStruct(&r,
+{{- range .Fields }}{{ if and (hasTag . "json") (not (hasTagOpt . "db" "pk")) }}{{ $rules := validationRules . $request }}{{ if or $rules .Validatable }}
+		validation.Field(&r.{{ .Name }}{{ range $rules }}, {{ . }}{{ end }}),
+{{- end }}{{ end }}{{ end }}
+	)
+}
+
+// apply sets the fields of the record by the request.
+func (r {{ $request }}) apply(record *{{ $type }}) {
+{{- range .Fields }}{{ if and (hasTag . "json") (not (hasTagOpt . "db" "pk")) }}
+	record.{{ .Name }} = r.{{ .Name }}
+{{- end }}{{ end }}
+}
+
+// {{ lowerFirst .TypeName }}Filters applies the filters of the query parameters to the {{ $name }} queries.
+func {{ lowerFirst .TypeName }}Filters(q {{ $m }}{{ .TypeName }}QI, query url.Values) ({{ $m }}{{ .TypeName }}QI, error) {
+{{- range .Fields }}{{ if and (hasTag . "db") (hasTag . "json") .Basic (not .Pointer) }}
+	if raw := query.Get("{{ .Tags.json }}"); raw != "" {
+		{{- template "filter" . }}
+	}
+{{- end }}{{ end }}
+	return q, nil
+}
+{{ if $pk }}
+// {{ lowerFirst .TypeName }}By{{ $pk.Name }} filters the {{ $name }} queries by the primary key.
+func {{ lowerFirst .TypeName }}By{{ $pk.Name }}(q {{ $m }}{{ .TypeName }}QI, raw string) ({{ $m }}{{ .TypeName }}QI, error) {
+	{{- template "filter" $pk }}
+	return q, nil
+}
+{{ end }}
+// {{ $handlers }} are the CRUD handlers of the {{ $name }}.
+type {{ $handlers }} struct {
+	logger *logrus.Entry
+}
+
+// list renders the page of the {{ $name }} matching the filters of the query.
+func (h {{ $handlers }}) list(w http.ResponseWriter, r *http.Request) {
+	pq, err := db.ParsePageQuery(r.URL.Query())
+	if err != nil {
+		render.BadRequest(w, err.Error())
+		return
+	}
+	q, err := {{ lowerFirst .TypeName }}Filters({{ $m }}NewQ(nil).{{ $plural }}(), r.URL.Query())
+	if err != nil {
+		render.BadRequest(w, err.Error())
+		return
+	}
+
+	records, err := q.SetPage(&pq).Select()
+	if err != nil {
+		h.logger.WithError(err).Error("unable to select {{ $name }}")
+		render.ServerError(w)
+		return
+	}
+	render.Success(w, records)
+}
+{{ if $pk }}
+// get renders the {{ .TypeName }} by the primary key.
+func (h {{ $handlers }}) get(w http.ResponseWriter, r *http.Request) {
+	record, ok := h.find(w, r)
+	if !ok {
+		return
+	}
+	render.Success(w, record)
+}
+{{ end }}
+// create inserts the {{ .TypeName }} of the request body.
+func (h {{ $handlers }}) create(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.bind(w, r)
+	if !ok {
+		return
+	}
+
+	record := new({{ $type }})
+	req.apply(record)
+	if err := {{ $m }}NewQ(nil).{{ $plural }}().Insert(record); err != nil {
+		h.logger.WithError(err).Error("unable to insert {{ snake .TypeName }}")
+		render.ServerError(w)
+		return
+	}
+	render.Success(w, record)
+}
+{{ if $pk }}
+// update updates the {{ .TypeName }} of the primary key by the request body.
+func (h {{ $handlers }}) update(w http.ResponseWriter, r *http.Request) {
+	record, ok := h.find(w, r)
+	if !ok {
+		return
+	}
+	req, ok := h.bind(w, r)
+	if !ok {
+		return
+	}
+
+	req.apply(record)
+	if err := {{ $m }}NewQ(nil).{{ $plural }}().Update(record); err != nil {
+		h.logger.WithError(err).Error("unable to update {{ snake .TypeName }}")
+		render.ServerError(w)
+		return
+	}
+	render.Success(w, record)
+}
+
+// delete removes the {{ .TypeName }} of the primary key and renders it.
+func (h {{ $handlers }}) delete(w http.ResponseWriter, r *http.Request) {
+	record, ok := h.find(w, r)
+	if !ok {
+		return
+	}
+
+	if err := {{ $m }}NewQ(nil).{{ $plural }}().Delete(record); err != nil {
+		h.logger.WithError(err).Error("unable to delete {{ snake .TypeName }}")
+		render.ServerError(w)
+		return
+	}
+	render.Success(w, record)
+}
+
+// find returns the {{ .TypeName }} of the primary key of the URL,
+// it renders the error response, if the record isn't found.
+func (h {{ $handlers }}) find(w http.ResponseWriter, r *http.Request) (*{{ $type }}, bool) {
+	q, err := {{ lowerFirst .TypeName }}By{{ $pk.Name }}({{ $m }}NewQ(nil).{{ $plural }}(), chi.URLParam(r, "{{ $pkParam }}"))
+	if err != nil {
+		render.BadRequest(w, err.Error())
+		return nil, false
+	}
+
+	record, err := q.Get()
+	if err != nil {
+		h.logger.WithError(err).Error("unable to get {{ snake .TypeName }}")
+		render.ServerError(w)
+		return nil, false
+	}
+	if record == nil {
+		render.ResultNotFound.Render(w)
+		return nil, false
+	}
+	return record, true
+}
+{{ end }}
+// bind decodes the request body and validates it, it renders the error response, if it's invalid.
+func (h {{ $handlers }}) bind(w http.ResponseWriter, r *http.Request) (*{{ $request }}, bool) {
+	req := new({{ $request }})
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		render.BadRequest(w, fmt.Sprintf("invalid request body: %v", err))
+		return nil, false
+	}
+	if err := req.Validate(); err != nil {
+		render.BadRequest(w, err)
+		return nil, false
+	}
+	return req, true
+}
+
+{{- define "filter" }}
+{{- $param := .Tags.json | default (lowerFirst .Name) }}
+{{- $bits := "64" }}
+{{- if contains .Basic "8" }}{{ $bits = "8" }}{{ else if contains .Basic "16" }}{{ $bits = "16" }}
+{{- else if contains .Basic "32" }}{{ $bits = "32" }}{{ else if not (contains .Basic "64") }}{{ $bits = "0" }}{{ end }}
+{{- if .Enum }}
+		// the enums are passed by the names, like they're marshaled to JSON
+		var value {{ .TypeRef }}
+		if err := json.Unmarshal([]byte(strconv.Quote(raw)), &value); err != nil {
+			return nil, fmt.Errorf("{{ $param }}: %v", err)
+		}
+		q = q.With{{ .Name }}(value)
+{{- else if eq .Basic "string" }}
+		q = q.With{{ .Name }}({{ if eq .TypeRef "string" }}raw{{ else }}{{ .TypeRef }}(raw){{ end }})
+{{- else if eq .Basic "bool" }}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("{{ $param }}: must be a boolean")
+		}
+		q = q.With{{ .Name }}({{ if eq .TypeRef "bool" }}value{{ else }}{{ .TypeRef }}(value){{ end }})
+{{- else if contains .Basic "uint" }}
+		value, err := strconv.ParseUint(raw, 10, {{ $bits }})
+		if err != nil {
+			return nil, fmt.Errorf("{{ $param }}: must be a non-negative integer")
+		}
+		q = q.With{{ .Name }}({{ if eq .TypeRef "uint64" }}value{{ else }}{{ .TypeRef }}(value){{ end }})
+{{- else if contains .Basic "int" }}
+		value, err := strconv.ParseInt(raw, 10, {{ $bits }})
+		if err != nil {
+			return nil, fmt.Errorf("{{ $param }}: must be an integer")
+		}
+		q = q.With{{ .Name }}({{ if eq .TypeRef "int64" }}value{{ else }}{{ .TypeRef }}(value){{ end }})
+{{- else if contains .Basic "float" }}
+		value, err := strconv.ParseFloat(raw, {{ if eq $bits "32" }}32{{ else }}64{{ end }})
+		if err != nil {
+			return nil, fmt.Errorf("{{ $param }}: must be a number")
+		}
+		q = q.With{{ .Name }}({{ if eq .TypeRef "float64" }}value{{ else }}{{ .TypeRef }}(value){{ end }})
+{{- else }}
+		var value {{ .TypeRef }}
+		if err := value.UnmarshalText([]byte(raw)); err != nil {
+			return nil, fmt.Errorf("{{ $param }}: %v", err)
+		}
+		q = q.With{{ .Name }}(value)
+{{- end }}
+{{- end }}
+`
@@ -0,0 +1,147 @@
+package templates
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenModelTemplate_builtin(t *testing.T) {
+	tmpl, err := OpenModelTemplate("builtin:armory-q")
+	require.NoError(t, err)
+
+	name, err := tmpl.OutputName("UserRole")
+	require.NoError(t, err)
+	assert.Equal(t, "user_role_q.go", name)
+
+	spec := ModelSpec{
+		Package:    "models",
+		TypeName:   "User",
+		TypeString: "user",
+		TypeRef:    "User",
+		Fields: []Field{
+			{Name: "ID", FType: "int64", TypeRef: "int64", qualified: "int64",
+				Tags: map[string]string{"db": "id"}, TagOpts: TagOptions{"db": {"pk": true}}},
+			{Name: "Name", FType: "string", TypeRef: "string", qualified: "string", Tags: map[string]string{"db": "name"}},
+			{Name: "Plain", FType: "int", TypeRef: "int", qualified: "int", Tags: map[string]string{}},
+		},
+	}
+	out, err := spec.Exec(tmpl.Template)
+	require.NoError(t, err)
+	_, err = format.Source([]byte(out))
+	require.NoError(t, err, out)
+
+	assert.Contains(t, out, `return q.conn.Get(query.Suffix("RETURNING id"), record)`)
+	assert.Contains(t, out, "func (q *UserQ) Update(record *User) error {")
+	assert.Contains(t, out, "func (q *UserQ) WithName(value string) UserQI {")
+	assert.Equal(t, 1, strings.Count(out, "ApplyPage("), out)
+	assert.NotContains(t, out, "func (q *Q)")
+	assert.NotContains(t, out, "WithPlain")
+
+	// the types of the fields are qualified in another package
+	spec.Fields = append(spec.Fields, Field{Name: "Role", FType: "Role", TypeRef: "Role", qualified: "models.Role",
+		Tags: map[string]string{"db": "role"}})
+	qualified := spec.Qualify("models")
+	out, err = qualified.Exec(tmpl.Template)
+	require.NoError(t, err)
+	assert.Contains(t, out, "WithRole(value models.Role) UserQI\n")
+	assert.Contains(t, out, "func (q *UserQ) WithRole(value models.Role) UserQI {")
+	assert.Contains(t, out, "func (q *UserQ) Get() (*models.User, error) {")
+
+	_, err = OpenModelTemplate("builtin:unknown")
+	assert.EqualError(t, err, `unknown built-in template "unknown", available: armory-q, armory-q-accessor, chi-crud, validate`)
+}
+
+func TestOpenModelTemplate_armoryQAccessor(t *testing.T) {
+	tmpl, err := OpenModelTemplate("builtin:armory-q-accessor")
+	require.NoError(t, err)
+	assert.Equal(t, ".", tmpl.Package)
+
+	out, err := (&ModelSpec{Package: "models", TypeName: "UserRole", TypeRef: "UserRole"}).Exec(tmpl.Template)
+	require.NoError(t, err)
+	_, err = format.Source([]byte(out))
+	require.NoError(t, err, out)
+	assert.Contains(t, out, "func (q *Q) UserRoles() UserRoleQI {\n\treturn NewUserRoleQ(q.SQLConn)\n}")
+}
+
+func TestOpenModelTemplate_chiCrud(t *testing.T) {
+	tmpl, err := OpenModelTemplate("builtin:chi-crud")
+	require.NoError(t, err)
+	assert.Equal(t, "../api", tmpl.Package)
+
+	spec := ModelSpec{
+		Package:  "api",
+		TypeName: "User",
+		TypeRef:  "User",
+		Command:  "model --type User --tmpl builtin:chi-crud",
+		Fields: []Field{
+			{Name: "ID", FType: "int64", Basic: "int64", qualified: "int64",
+				Tags: map[string]string{"db": "id", "json": "id"}, TagOpts: TagOptions{"db": {"pk": true}}},
+			{Name: "Role", FType: "Role", Basic: "string", qualified: "models.Role",
+				Tags:    map[string]string{"db": "role", "json": "role", "validate": "in=admin|user"},
+				rawTags: map[string]string{"db": "role", "json": "role", "validate": "in=admin|user"}},
+			{Name: "Age", FType: "*int", Basic: "int", Pointer: true, qualified: "*int",
+				Tags: map[string]string{"db": "age", "json": "age"}, TagOpts: TagOptions{"json": {"omitempty": true}}},
+			{Name: "Login", FType: "string", Basic: "string", qualified: "string",
+				Tags:    map[string]string{"db": "login", "json": "login", "validate": "required,match=^[a-z]+$"},
+				rawTags: map[string]string{"db": "login", "json": "login", "validate": "required,match=^[a-z]+$"}},
+			{Name: "Secret", FType: "string", Basic: "string", qualified: "string",
+				Tags: map[string]string{"db": "secret"}},
+			{Name: "Status", FType: "Status", Basic: "int", Enum: true, qualified: "models.Status",
+				Tags: map[string]string{"db": "status", "json": "status"}},
+		},
+	}.Qualify("models")
+	out, err := spec.Exec(tmpl.Template)
+	require.NoError(t, err)
+	_, err = format.Source([]byte(out))
+	require.NoError(t, err, out)
+
+	assert.True(t, strings.HasPrefix(out, "// Code generated by forge model --type User --tmpl builtin:chi-crud; DO NOT EDIT.\n\npackage api\n"), out)
+	assert.Contains(t, out, `r.Route("/{id}", func(r chi.Router) {`)
+	assert.Contains(t, out, "Role models.Role `json:\"role\"`")
+	assert.Contains(t, out, "Age *int `json:\"age,omitempty\"`")
+	assert.Contains(t, out, `validation.Field(&r.Role, validation.In(models.Role("admin"), models.Role("user"))),`)
+	// the regular expressions are compiled once
+	assert.Contains(t, out, "var (\n\tuserRequestLoginPattern = regexp.MustCompile(\"^[a-z]+$\")\n)")
+	assert.Contains(t, out, `validation.Field(&r.Login, validation.Required, validation.Match(userRequestLoginPattern)),`)
+	assert.Contains(t, out, `q = q.WithRole(models.Role(raw))`)
+	assert.Contains(t, out, `q = q.WithID(value)`)
+	// the enums are filtered by the names
+	assert.Contains(t, out, "var value models.Status\n\t\tif err := json.Unmarshal([]byte(strconv.Quote(raw)), &value); err != nil {")
+	assert.Contains(t, out, `q = q.WithStatus(value)`)
+	assert.Contains(t, out, "func (h userHandlers) find(w http.ResponseWriter, r *http.Request) (*models.User, bool) {")
+	assert.Contains(t, out, "models.NewQ(nil).Users().Insert(record)")
+	assert.NotContains(t, out, "Secret")
+	assert.NotContains(t, out, "WithAge")
+}
+
+func TestOpenModelTemplate_validate(t *testing.T) {
+	tmpl, err := OpenModelTemplate("builtin:validate")
+	require.NoError(t, err)
+	assert.Equal(t, ".", tmpl.Package)
+
+	tag := "match=^[a-z]+$,length=:8,match=^[^x]"
+	spec := ModelSpec{
+		Package:  "models",
+		TypeName: "User",
+		TypeRef:  "User",
+		Fields: []Field{
+			{Name: "Name", FType: "string", Basic: "string", qualified: "string",
+				Tags: map[string]string{"validate": tag}, rawTags: map[string]string{"validate": tag}},
+			{Name: "Email", FType: "string", Basic: "string", qualified: "string",
+				Tags: map[string]string{"validate": "email"}, rawTags: map[string]string{"validate": "email"}},
+		},
+	}
+	out, err := spec.Exec(tmpl.Template)
+	require.NoError(t, err)
+	_, err = format.Source([]byte(out))
+	require.NoError(t, err, out)
+
+	assert.Contains(t, out, "var (\n\tuserNamePattern = regexp.MustCompile(\"^[a-z]+$\")\n\tuserNamePattern2 = regexp.MustCompile(\"^[^x]\")\n)")
+	assert.Contains(t, out, "validation.Field(&u.Name, validation.Match(userNamePattern), validation.Length(0, 8), validation.Match(userNamePattern2)),")
+	assert.Contains(t, out, "validation.Field(&u.Email, is.Email),")
+	assert.NotContains(t, out, "validation.Match(regexp.")
+}
@@ -0,0 +1,124 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// frontMatterDelimiter opens and closes the front matter of the template.
+const frontMatterDelimiter = "---"
+
+// FrontMatter is the YAML header of the model template placed between `---` lines:
+//
+//	---
+//	output: "{{ snake .TypeName }}_repo.go"
+//	package: ../repo
+//	---
+type FrontMatter struct {
+	// Output is the template of the output file name, it's executed
+	// with the TypeName and TypeString of the model and the Funcs.
+	Output string `yaml:"output"`
+	// Package is the directory of the output package,
+	// relative paths are resolved from the source package directory.
+	Package string `yaml:"package"`
+}
+
+// ModelTemplate is the parsed model template and its front matter.
+type ModelTemplate struct {
+	Path     string
+	Template *template.Template
+	FrontMatter
+	output *template.Template
+}
+
+// OpenModelTemplate parses the template with the Funcs. Partials, the files named
+// like `_row.tmpl` in the directory of the template, are parsed as well,
+// so they can be executed by the template or the include function.
+// The front matter, if present, is removed from the template.
+// The path can refer to the built-in template, see BuiltinPrefix.
+func OpenModelTemplate(templatePath string) (*ModelTemplate, error) {
+	raw, err := ReadTemplate(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	header, body := splitFrontMatter(string(raw))
+	result := &ModelTemplate{Path: templatePath}
+	if err := yaml.UnmarshalStrict([]byte(header), &result.FrontMatter); err != nil {
+		return nil, fmt.Errorf("%s: invalid front matter: %v", templatePath, err)
+	}
+
+	if result.Output != "" {
+		result.output = template.New("output")
+		result.output.Funcs(Funcs(result.output))
+		if _, err := result.output.Parse(result.Output); err != nil {
+			return nil, fmt.Errorf("%s: invalid output of the front matter: %v", templatePath, err)
+		}
+	}
+
+	tmpl := template.New(filepath.Base(templatePath))
+	tmpl.Funcs(Funcs(tmpl))
+	if _, err := tmpl.Parse(body); err != nil {
+		return nil, TemplateError(templatePath, err)
+	}
+
+	partials, err := Partials(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(partials) > 0 {
+		if _, err := tmpl.ParseFiles(partials...); err != nil {
+			return nil, TemplateError(templatePath, err)
+		}
+	}
+
+	result.Template = tmpl
+	return result, nil
+}
+
+// OutputName returns the name of the output file for the type
+// or empty string, if the output isn't set by the front matter.
+func (tmpl *ModelTemplate) OutputName(typeName string) (string, error) {
+	if tmpl.output == nil {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	spec := ModelSpec{TypeName: typeName, TypeString: lowerFirst(typeName), TypeRef: typeName}
+	if err := tmpl.output.Execute(&buf, spec); err != nil {
+		return "", fmt.Errorf("%s: executing output of the front matter: %v", tmpl.Path, err)
+	}
+
+	name := strings.TrimSpace(buf.String())
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%s: output of the front matter should be a file name, got %q", tmpl.Path, name)
+	}
+	return name, nil
+}
+
+// splitFrontMatter splits the template into the front matter and the body.
+// The front matter is replaced by the comment of the same number of lines,
+// so positions of the errors in the body aren't changed.
+func splitFrontMatter(src string) (header, body string) {
+	lines := strings.SplitAfter(src, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != frontMatterDelimiter {
+		return "", src
+	}
+
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != frontMatterDelimiter {
+			continue
+		}
+
+		header = strings.Join(lines[1:i], "")
+		// the newline after the closing delimiter is trimmed as well
+		body = "{{/*" + strings.Repeat("\n", i) + "*/ -}}\n" + strings.Join(lines[i+1:], "")
+		return header, body
+	}
+	return "", src
+}
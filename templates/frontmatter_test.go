@@ -0,0 +1,34 @@
+package templates
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenModelTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "repo.tmpl")
+	src := "---\noutput: \"{{ snake .TypeName }}_repo.go\"\npackage: ../repo\n---\npackage {{ .Package }}\n{{ .Unknown }}\n"
+	require.NoError(t, ioutil.WriteFile(path, []byte(src), 0644))
+
+	tmpl, err := OpenModelTemplate(path)
+	require.NoError(t, err)
+	assert.Equal(t, FrontMatter{Output: "{{ snake .TypeName }}_repo.go", Package: "../repo"}, tmpl.FrontMatter)
+
+	name, err := tmpl.OutputName("UserRole")
+	require.NoError(t, err)
+	assert.Equal(t, "user_role_repo.go", name)
+
+	// positions of the body are kept
+	_, err = (&ModelSpec{Package: "repo"}).Exec(tmpl.Template)
+	require.Error(t, err)
+	assert.Contains(t, TemplateError(path, err).Error(), "repo.tmpl:6:")
+}
@@ -0,0 +1,284 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/fatih/camelcase"
+)
+
+// Funcs returns the functions available in the model templates.
+// The include function executes the named template of the tmpl,
+// so tmpl should be the template, which the functions are added to.
+func Funcs(tmpl *template.Template) template.FuncMap {
+	return template.FuncMap{
+		"snake":              func(s string) string { return transformString(s, "_") },
+		"kebab":              func(s string) string { return transformString(s, "-") },
+		"camel":              camel,
+		"lowerFirst":         lowerFirst,
+		"plural":             plural,
+		"singular":           singular,
+		"quote":              strconv.Quote,
+		"join":               join,
+		"contains":           contains,
+		"hasTag":             hasTag,
+		"hasTagOpt":          hasTagOpt,
+		"tagOpt":             tagOpt,
+		"isPointer":          func(field Field) bool { return field.Pointer },
+		"isSlice":            func(field Field) bool { return field.Slice },
+		"isMap":              func(field Field) bool { return field.Map },
+		"isTime":             isTime,
+		"default":            defaultValue,
+		"validationRules":    validationRules,
+		"validationPatterns": validationPatterns,
+		"include": func(name string, data interface{}) (string, error) {
+			var buf bytes.Buffer
+			if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		},
+	}
+}
+
+// words splits the identifier or the snake, kebab or space separated string into words.
+func words(s string) []string {
+	var result []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == '_' || r == '-' || unicode.IsSpace(r)
+	}) {
+		result = append(result, camelcase.Split(part)...)
+	}
+	return result
+}
+
+// camel converts the string into lowerCamelCase: `user_id` and `UserID` become `userId`.
+func camel(s string) string {
+	var result string
+	for i, word := range words(s) {
+		word = strings.ToLower(word)
+		if i > 0 {
+			word = strings.Title(word)
+		}
+		result += word
+	}
+	return result
+}
+
+func lowerFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToLower(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
+
+// irregularPlurals are the English nouns, which don't follow the rules.
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"child":  "children",
+	"man":    "men",
+	"woman":  "women",
+	"mouse":  "mice",
+	"goose":  "geese",
+	"tooth":  "teeth",
+	"foot":   "feet",
+}
+
+// plural returns the plural form of the English noun, the last word of the identifier
+// is changed only, so `UserCategory` becomes `UserCategories` and `ID` becomes `IDs`.
+func plural(s string) string {
+	prefix, word := splitLastWord(s)
+	lower := strings.ToLower(word)
+	for single, many := range irregularPlurals {
+		if lower == single {
+			return prefix + sameCase(word, many)
+		}
+	}
+
+	switch {
+	case lower == "":
+		return s
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !isVowel(lower[len(lower)-2]):
+		return prefix + word[:len(word)-1] + "ies"
+	case strings.HasSuffix(lower, "s") || strings.HasSuffix(lower, "x") || strings.HasSuffix(lower, "z") ||
+		strings.HasSuffix(lower, "ch") || strings.HasSuffix(lower, "sh"):
+		return prefix + word + "es"
+	default:
+		return prefix + word + "s"
+	}
+}
+
+// singular returns the singular form of the English noun, the inverse of plural.
+func singular(s string) string {
+	prefix, word := splitLastWord(s)
+	lower := strings.ToLower(word)
+	for single, many := range irregularPlurals {
+		if lower == many {
+			return prefix + sameCase(word, single)
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(lower) > 3:
+		return prefix + word[:len(word)-3] + "y"
+	case strings.HasSuffix(lower, "sses") || strings.HasSuffix(lower, "uses") || strings.HasSuffix(lower, "xes") ||
+		strings.HasSuffix(lower, "zes") || strings.HasSuffix(lower, "ches") || strings.HasSuffix(lower, "shes"):
+		return prefix + word[:len(word)-2]
+	case strings.HasSuffix(lower, "ss") || strings.HasSuffix(lower, "us") || strings.HasSuffix(lower, "is"):
+		return s
+	case strings.HasSuffix(lower, "s"):
+		return prefix + word[:len(word)-1]
+	default:
+		return s
+	}
+}
+
+// splitLastWord splits the identifier before its last word.
+func splitLastWord(s string) (prefix, word string) {
+	list := words(s)
+	if len(list) == 0 {
+		return "", s
+	}
+	word = list[len(list)-1]
+	if !strings.HasSuffix(s, word) {
+		return "", s
+	}
+	return s[:len(s)-len(word)], word
+}
+
+// sameCase returns the replacement of the word in the same case as the word:
+// in upper case for `PERSON`, capitalized for `Person` and as is otherwise.
+func sameCase(word, replacement string) string {
+	switch {
+	case len(word) > 1 && word == strings.ToUpper(word):
+		return strings.ToUpper(replacement)
+	case unicode.IsUpper(rune(word[0])):
+		return strings.Title(replacement)
+	default:
+		return replacement
+	}
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aeiou", c) >= 0
+}
+
+// join joins the elements of the slice by the separator,
+// elements are formatted by fmt.Sprint.
+func join(sep string, list interface{}) (string, error) {
+	value := reflect.ValueOf(list)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: %T is not a slice", list)
+	}
+
+	items := make([]string, value.Len())
+	for i := range items {
+		items[i] = fmt.Sprint(value.Index(i).Interface())
+	}
+	return strings.Join(items, sep), nil
+}
+
+// contains reports whether the string contains the substring,
+// the slice contains the element or the map contains the key.
+func contains(collection interface{}, item interface{}) (bool, error) {
+	if s, ok := collection.(string); ok {
+		return strings.Contains(s, fmt.Sprint(item)), nil
+	}
+
+	value := reflect.ValueOf(collection)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if reflect.DeepEqual(value.Index(i).Interface(), item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case reflect.Map:
+		key := reflect.ValueOf(item)
+		if !key.IsValid() || !key.Type().AssignableTo(value.Type().Key()) {
+			return false, nil
+		}
+		return value.MapIndex(key).IsValid(), nil
+	case reflect.Invalid:
+		return false, nil
+	default:
+		return false, fmt.Errorf("contains: %T is not a string, slice or map", collection)
+	}
+}
+
+// hasTag reports whether the field has the tag with the key.
+func hasTag(field Field, key string) bool {
+	_, ok := field.Tags[key]
+	return ok
+}
+
+// hasTagOpt reports whether the tag of the field has the option,
+// like `pk` of `db:"id,pk"`.
+func hasTagOpt(field Field, key, option string) bool {
+	return field.TagOpts[key][option]
+}
+
+// tagOpt returns the value of the `name=value` option of the field tag,
+// like `uuid` of the `type` option of `db:"id,type=uuid"`.
+func tagOpt(field Field, key, name string) string {
+	for option := range field.TagOpts[key] {
+		if strings.HasPrefix(option, name+"=") {
+			return strings.TrimPrefix(option, name+"=")
+		}
+	}
+	return ""
+}
+
+// isTime reports whether the field is time.Time or a pointer to it.
+func isTime(field Field) bool {
+	return field.ImportPath == "time" && strings.TrimLeft(field.FType, "*") == "time.Time"
+}
+
+// defaultValue returns the value or, if it's empty, the default one:
+// `{{ .Tags.db | default "id" }}`.
+func defaultValue(def interface{}, value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return def
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		if v.Len() == 0 {
+			return def
+		}
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return def
+		}
+	case reflect.Bool:
+		if !v.Bool() {
+			return def
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() == 0 {
+			return def
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() == 0 {
+			return def
+		}
+	case reflect.Float32, reflect.Float64:
+		if v.Float() == 0 {
+			return def
+		}
+	}
+	return value
+}
+
+// TableName returns the name of the table of the type used by the built-in
+// templates and migrations: `UserRole` becomes `user_roles`.
+func TableName(typeName string) string {
+	return plural(transformString(typeName, "_"))
+}
@@ -0,0 +1,89 @@
+package templates
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncs_strings(t *testing.T) {
+	cases := []struct {
+		fn   func(string) string
+		in   string
+		want string
+	}{
+		{fn: camel, in: "user_id", want: "userId"},
+		{fn: camel, in: "UserID", want: "userId"},
+		{fn: lowerFirst, in: "UserID", want: "userID"},
+		{fn: plural, in: "User", want: "Users"},
+		{fn: plural, in: "UserCategory", want: "UserCategories"},
+		{fn: plural, in: "Day", want: "Days"},
+		{fn: plural, in: "box", want: "boxes"},
+		{fn: plural, in: "Person", want: "People"},
+		{fn: plural, in: "ID", want: "IDs"},
+		{fn: singular, in: "IDs", want: "ID"},
+		{fn: singular, in: "UserCategories", want: "UserCategory"},
+		{fn: singular, in: "statuses", want: "status"},
+		{fn: singular, in: "users", want: "user"},
+		{fn: singular, in: "people", want: "person"},
+		{fn: singular, in: "Status", want: "Status"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, tc.fn(tc.in), tc.in)
+	}
+}
+
+func TestFuncs_fields(t *testing.T) {
+	field := Field{
+		Name:       "CreatedAt",
+		FType:      "*time.Time",
+		ImportPath: "time",
+		Pointer:    true,
+		Tags:       map[string]string{"db": "created_at"},
+		TagOpts:    TagOptions{"db": {"readonly": true, "type=timestamptz": true}},
+	}
+
+	assert.True(t, isTime(field))
+	assert.True(t, hasTag(field, "db"))
+	assert.False(t, hasTag(field, "json"))
+	assert.True(t, hasTagOpt(field, "db", "readonly"))
+	assert.Equal(t, "timestamptz", tagOpt(field, "db", "type"))
+	assert.Equal(t, "", tagOpt(field, "json", "type"))
+
+	assert.Equal(t, "id", defaultValue("id", ""))
+	assert.Equal(t, "uid", defaultValue("id", "uid"))
+
+	ok, err := contains([]string{"a", "b"}, "b")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = contains(field.Tags, "json")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestOpenTemplate_include(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "model.tmpl")
+	require.NoError(t, ioutil.WriteFile(path,
+		[]byte(`{{ range .Fields }}{{ include "_column.tmpl" . | printf "%q" }} {{ end }}`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "_column.tmpl"),
+		[]byte(`{{ .Tags.db | default (snake .Name) }}`), 0644))
+
+	tmpl, err := OpenTemplate(path)
+	require.NoError(t, err)
+
+	out, err := (&ModelSpec{Fields: []Field{
+		{Name: "UserID", Tags: map[string]string{"db": "uid"}},
+		{Name: "CreatedAt"},
+	}}).Exec(tmpl)
+	require.NoError(t, err)
+	assert.Equal(t, `"uid" "created_at" `, out)
+}
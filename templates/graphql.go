@@ -0,0 +1,81 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/fatih/camelcase"
+)
+
+var schemaTemplate = template.Must(template.New("graphql").Parse(schemaRaw))
+
+var schemaRaw = `{{range .}}
+enum {{.TypeName}} {
+{{- range .Values}}{{if not .Alias}}
+  {{.GQL}}{{end}}{{end}}
+}
+{{end}}`
+
+// GraphQLName converts the string to the enum value name, which satisfies
+// GraphQL naming rules (/[_A-Za-z][_0-9A-Za-z]*/) and convention (SCREAMING_SNAKE_CASE).
+func GraphQLName(src string) string {
+	notAlnum := func(r rune) bool {
+		return r >= unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	}
+
+	var words []string
+	for _, entry := range camelcase.Split(src) {
+		words = append(words, strings.FieldsFunc(entry, notAlnum)...)
+	}
+
+	name := strings.ToUpper(strings.Join(words, "_"))
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	return name
+}
+
+// GenerateSchema returns the GraphQL schema definitions
+// of the types, which have GraphQL output enabled.
+func (analysis *Analysis) GenerateSchema(merge bool) (map[string][]byte, error) {
+	var results = make(map[string][]byte)
+
+	var specs []TypeSpec
+	for _, typeName := range analysis.typeNames() {
+		spec := analysis.Types[typeName]
+		if !spec.GraphQL {
+			continue
+		}
+
+		specs = append(specs, spec)
+		if !merge {
+			schema, err := analysis.schemaOf(specs)
+			if err != nil {
+				return nil, err
+			}
+			results[typeName] = schema
+			specs = nil
+		}
+	}
+	if merge && len(specs) > 0 {
+		schema, err := analysis.schemaOf(specs)
+		if err != nil {
+			return nil, err
+		}
+		results["all"] = schema
+	}
+
+	return results, nil
+}
+
+func (analysis *Analysis) schemaOf(specs []TypeSpec) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("# generated by forge " + analysis.Command + "; DO NOT EDIT\n")
+	if err := schemaTemplate.Execute(&buf, specs); err != nil {
+		return nil, fmt.Errorf("generating schema: %v", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,22 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphQLName(t *testing.T) {
+	tests := map[string]string{
+		"Red":       "RED",
+		"DarkBlue":  "DARK_BLUE",
+		"TTL":       "TTL",
+		"dark blue": "DARK_BLUE",
+		"x-large":   "X_LARGE",
+		"2XL":       "_2_XL",
+		"":          "_",
+	}
+	for src, expected := range tests {
+		assert.Equal(t, expected, GraphQLName(src), "source: %q", src)
+	}
+}
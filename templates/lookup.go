@@ -0,0 +1,85 @@
+package templates
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// LookupMode defines how the generated code finds the string of value.
+type LookupMode string
+
+const (
+	// LookupMap uses the def<Type>ValueToName map.
+	LookupMap LookupMode = "map"
+	// LookupIndex uses the concatenated names and the array of their offsets,
+	// like stringer does, it is applicable only for contiguous values.
+	LookupIndex LookupMode = "index"
+	// LookupSwitch uses the switch statement over the values.
+	LookupSwitch LookupMode = "switch"
+	// LookupAuto chooses LookupIndex for the contiguous values,
+	// and LookupSwitch for others.
+	LookupAuto LookupMode = "auto"
+)
+
+// Validate is an implementation of Validatable interface.
+func (mode LookupMode) Validate() error {
+	switch mode {
+	case LookupMap, LookupIndex, LookupSwitch, LookupAuto:
+		return nil
+	default:
+		return fmt.Errorf("LookupMode(%s) is invalid", mode)
+	}
+}
+
+// Shift returns the expression, which turns value into
+// the index of LookupIndex arrays, when appended to int64(value).
+func (spec TypeSpec) Shift() string {
+	switch {
+	case spec.Min > 0:
+		return " - " + strconv.FormatInt(spec.Min, 10)
+	case spec.Min < 0:
+		// -Min overflows for math.MinInt64, so print it as unsigned
+		return " + " + strconv.FormatUint(uint64(-(spec.Min+1))+1, 10)
+	default:
+		return ""
+	}
+}
+
+// NameOffsets returns the offsets of the names of the ordered values
+// in their concatenation, the last offset is the length of concatenation.
+func (spec TypeSpec) NameOffsets() []int {
+	offsets := make([]int, 0, len(spec.Ordered)+1)
+	offset := 0
+	for _, v := range spec.Ordered {
+		offsets = append(offsets, offset)
+		offset += len(v.Str)
+	}
+	return append(offsets, offset)
+}
+
+// IndexType returns the smallest unsigned type, which can hold NameOffsets.
+func (spec TypeSpec) IndexType() string {
+	offsets := spec.NameOffsets()
+	switch last := offsets[len(offsets)-1]; {
+	case last <= 0xFF:
+		return "uint8"
+	case last <= 0xFFFF:
+		return "uint16"
+	default:
+		return "uint32"
+	}
+}
+
+var lookupRaw = `
+{{- if eq .Lookup "index"}}
+const _{{.TypeName}}_name = "{{range .Ordered}}{{.Str}}{{end}}"
+
+var _{{.TypeName}}_index = [...]{{.IndexType}}{ {{- range $i, $o := .NameOffsets}}{{if $i}}, {{end}}{{$o}}{{end -}} }
+{{end}}
+{{- if and (ne .Lookup "map") (not (index .ExcludeList "MarshalJSON"))}}
+// _{{.TypeName}}_json holds the precomputed JSON strings of the values.
+var _{{.TypeName}}_json = [...][]byte{
+    {{range .Ordered}}[]byte(` + "`" + `"{{.Str}}"` + "`" + `),
+    {{end}}
+}
+{{end}}`
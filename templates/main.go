@@ -2,15 +2,22 @@ package templates
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"go/format"
-	"html/template"
 	"log"
+	"sort"
+	"strings"
+	"text/template"
 )
 
 type CodeTemplate struct {
-	Name   string
-	Raw    string
-	Parsed *template.Template
+	Name string
+	Raw  string
+	// Imports is a list of the packages used by the template.
+	Imports []string
+	Parsed  *template.Template
 }
 
 func (r *CodeTemplate) parse() {
@@ -27,22 +34,62 @@ type TypeSpec struct {
 	TypeName    string
 	Values      []TypeValue
 	ExcludeList map[string]bool
+	// GraphQL is true if the GraphQL schema of the type should be generated.
+	GraphQL bool
+
+	// Lookup is a way to find the string of value, see LookupMode.
+	// LookupAuto must be resolved before generation.
+	Lookup LookupMode
+	// Ordered is a list of the canonical values sorted by value,
+	// it is used by LookupIndex and LookupSwitch.
+	Ordered []TypeValue
+	// Min is the minimal value, it is used by LookupIndex.
+	Min int64
 }
 
 type TypeValue struct {
 	Name string
 	Str  string
+	// Alias is true for the constant, which value is shared
+	// with another one, it is accepted on parse only.
+	Alias bool
+	// GQL is the name of the value in GraphQL schema.
+	GQL string
 }
 
-func (analysis *Analysis) GenerateByTemplate(merge bool) map[string][]byte {
-	var results = make(map[string][]byte)
+// Fingerprint returns the hash of all built-in enum templates,
+// it changes whenever the generated code may change.
+func Fingerprint() string {
+	h := sha256.New()
+	for _, tmpl := range append([]CodeTemplate{FileBase, BSONBase}, append(EnumBase, EnumBSON...)...) {
+		fmt.Fprintf(h, "%s\x00%s\x00%q\x00", tmpl.Name, tmpl.Raw, tmpl.Imports)
+	}
+	h.Write([]byte(schemaRaw))
+	return hex.EncodeToString(h.Sum(nil))
+}
 
-	var buf bytes.Buffer
+// GenerateByTemplate returns the code of the enum methods for the types.
+func (analysis *Analysis) GenerateByTemplate(merge bool) (map[string][]byte, error) {
+	return analysis.generate(merge, FileBase, EnumBase, "")
+}
+
+// GenerateBSON returns the code of BSON marshaling methods for the types,
+// which have them enabled. The code is guarded by the build tag.
+func (analysis *Analysis) GenerateBSON(merge bool, buildTag string) (map[string][]byte, error) {
+	return analysis.generate(merge, BSONBase, EnumBSON, buildTag)
+}
+
+// generate executes the templates for each type and prepends the header to result.
+// If the build tag is set, the types without any executed template are skipped.
+func (analysis *Analysis) generate(merge bool, header CodeTemplate, tmpls []CodeTemplate, buildTag string) (map[string][]byte, error) {
+	var results = make(map[string][]byte)
 
-	FileBase.Parsed.Execute(&buf, analysis)
+	var body bytes.Buffer
+	imports := map[string]bool{}
 
-	for typeName, spec := range analysis.Types {
-		for _, t := range EnumBase {
+	for _, typeName := range analysis.typeNames() {
+		spec := analysis.Types[typeName]
+		for _, t := range tmpls {
 			_, excludeList := spec.ExcludeList[t.Name]
 			//_, haveSpare := Spare[t.Name]
 			if excludeList {
@@ -50,19 +97,32 @@ func (analysis *Analysis) GenerateByTemplate(merge bool) map[string][]byte {
 				continue
 				//}
 			}
-			if err := t.Parsed.Execute(&buf, &spec); err != nil {
-				log.Fatalf("generating code: %v", err)
+			if err := t.Parsed.Execute(&body, &spec); err != nil {
+				return nil, fmt.Errorf("executing template %s for type %s: %v", t.Name, typeName, err)
+			}
+			for _, path := range t.Imports {
+				imports[path] = true
 			}
 		}
 
 		if !merge {
-			results[typeName] = buf.Bytes()
-			buf = bytes.Buffer{}
-			FileBase.Parsed.Execute(&buf, analysis)
+			if body.Len() > 0 || buildTag == "" {
+				src, err := analysis.withHeader(header, buildTag, imports, body.Bytes())
+				if err != nil {
+					return nil, err
+				}
+				results[typeName] = src
+			}
+			body = bytes.Buffer{}
+			imports = map[string]bool{}
 		}
 	}
-	if merge {
-		results["all"] = buf.Bytes()
+	if merge && (body.Len() > 0 || buildTag == "") {
+		src, err := analysis.withHeader(header, buildTag, imports, body.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		results["all"] = src
 	}
 
 	var err error
@@ -77,5 +137,60 @@ func (analysis *Analysis) GenerateByTemplate(merge bool) map[string][]byte {
 		}
 	}
 
-	return results
+	return results, nil
+}
+
+// typeNames returns the sorted names of types,
+// to keep output stable between invocations.
+func (analysis *Analysis) typeNames() []string {
+	names := make([]string, 0, len(analysis.Types))
+	for name := range analysis.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withHeader prepends to the body the file header
+// with package clause and imports required by the body.
+func (analysis *Analysis) withHeader(tmpl CodeTemplate, buildTag string, imports map[string]bool, body []byte) ([]byte, error) {
+	header := fileHeader{
+		Command:     analysis.Command,
+		PackageName: analysis.PackageName,
+		BuildTag:    buildTag,
+	}
+	var std, thirdParty []string
+	for path := range imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			thirdParty = append(thirdParty, path)
+			continue
+		}
+		std = append(std, path)
+	}
+	sort.Strings(std)
+	sort.Strings(thirdParty)
+
+	// empty path separates the groups of imports
+	header.Imports = std
+	if len(std) > 0 && len(thirdParty) > 0 {
+		header.Imports = append(header.Imports, "")
+	}
+	header.Imports = append(header.Imports, thirdParty...)
+
+	var buf bytes.Buffer
+	if err := tmpl.Parsed.Execute(&buf, header); err != nil {
+		return nil, fmt.Errorf("executing template %s: %v", tmpl.Name, err)
+	}
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+type fileHeader struct {
+	Command     string
+	PackageName string
+	// Imports is a list of the imports required by
+	// the templates in addition to the base ones.
+	Imports []string
+	// BuildTag is a build constraint of the file.
+	BuildTag string
 }
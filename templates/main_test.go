@@ -0,0 +1,21 @@
+package templates
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeTemplate_verbatim(t *testing.T) {
+	tmpl := CodeTemplate{Name: "Less", Raw: `func (r {{.TypeName}}) Less(v {{.TypeName}}) bool { return r < v && "{{range .Values}}{{.Str}}{{end}}" != "" }`}
+	tmpl.parse()
+
+	var buf bytes.Buffer
+	spec := TypeSpec{TypeName: "Op", Values: []TypeValue{{Name: "OpLess", Str: "<"}, {Name: "OpAnd", Str: "&&"}}}
+	if !assert.NoError(t, tmpl.Parsed.Execute(&buf, &spec)) {
+		return
+	}
+	// the Go code and the values are not escaped as HTML
+	assert.Equal(t, `func (r Op) Less(v Op) bool { return r < v && "<&&" != "" }`, buf.String())
+}
@@ -2,9 +2,14 @@ package templates
 
 import (
 	"bytes"
+	"fmt"
+	"go/token"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
 
 	"github.com/pkg/errors"
 
@@ -16,14 +21,60 @@ type ModelSpec struct {
 	TypeName   string
 	TypeString string
 	Fields     []Field
+	// TypeRef is a reference to the type from the output package,
+	// it's qualified (like `models.User`) if the output is in another package.
+	TypeRef string
+	// Qualifier is the prefix of the identifiers of the type package, like `models.`,
+	// it's set only if the output is in another package.
+	Qualifier string
+	// SourceImport is an import path of the type package,
+	// it's set only if the output is in another package.
+	SourceImport string
+	// Command is the forge command generating the output,
+	// the built-in templates write it into the header.
+	Command string
 }
 
 type Field struct {
-	Name  string
+	Name string
+	// FType is the type as it's written in the package of the struct,
+	// like `*time.Time`, `[]Item` or `map[string]int`.
 	FType string
-	Tags  map[string]string
+	// TypeRef is the type as it's written in the output package,
+	// it's qualified (like `[]models.Item`) if the output is in another package.
+	TypeRef string
+	// ImportPath is the import path of the package of the field type,
+	// see parser.FieldType.
+	ImportPath string
+	// KeyImportPath is the import path of the package of the map key type,
+	// see parser.FieldType.
+	KeyImportPath string
+	// Basic is the basic underlying type, see parser.FieldType.
+	Basic string
+	// Enum reports whether the field is a forge enum, see parser.FieldType.
+	Enum bool
+	// Validatable reports whether the field can be validated
+	// by the Validate method of its type, see parser.FieldType.
+	Validatable bool
+	Pointer     bool
+	Slice       bool
+	Map         bool
+	Embedded    bool
+	// Tags are the first values of the field tags, like `id` of `db:"id,pk"`.
+	Tags map[string]string
+	// TagOpts are the options of the field tags, see TagOptions.
+	TagOpts TagOptions
+
+	// rawTags are the whole values of the field tags, like `id,pk` of `db:"id,pk"`.
+	rawTags   map[string]string
+	qualified string
 }
 
+// TagOptions are the options of the field tags following the first value:
+// `db:"id,pk"` is represented as TagOptions{"db": {"pk": true}},
+// so templates can check them like `{{ if .TagOpts.db.pk }}`.
+type TagOptions map[string]map[string]bool
+
 func (spec *ModelSpec) Exec(tmpl *template.Template) (string, error) {
 	var buf bytes.Buffer
 
@@ -34,12 +85,57 @@ func (spec *ModelSpec) Exec(tmpl *template.Template) (string, error) {
 	return buf.String(), nil
 }
 
+// Qualify returns the spec referring to the type and the types of its fields
+// from another package, which imports the type package by the name.
+func (spec ModelSpec) Qualify(pkgName string) ModelSpec {
+	spec.Qualifier = pkgName + "."
+	spec.TypeRef = spec.Qualifier + spec.TypeName
+
+	fields := make([]Field, len(spec.Fields))
+	for i, field := range spec.Fields {
+		field.TypeRef = field.qualified
+		fields[i] = field
+	}
+	spec.Fields = fields
+	return spec
+}
+
+// OpenTemplate parses the template with the Funcs, see OpenModelTemplate.
 func OpenTemplate(templatePath string) (*template.Template, error) {
-	tmpl, err := template.ParseFiles(templatePath)
+	tmpl, err := OpenModelTemplate(templatePath)
 	if err != nil {
-		return nil, errors.Wrap(err, "invalid template")
+		return nil, err
 	}
-	return tmpl, nil
+	return tmpl.Template, nil
+}
+
+// Partials returns the paths of the partials of the template,
+// built-in templates have no partials.
+func Partials(templatePath string) ([]string, error) {
+	if IsBuiltin(templatePath) {
+		return nil, nil
+	}
+	return filepath.Glob(filepath.Join(filepath.Dir(templatePath), "_*.tmpl"))
+}
+
+var templateErrRe = regexp.MustCompile(`(?s)template: ([^:]*):(\d+):(?:(\d+):)? (.*)$`)
+
+// TemplateError converts the error of parsing or executing the template
+// placed in the path into the error with position in the template
+// or in its partial. Other errors are returned as is.
+func TemplateError(templatePath string, err error) error {
+	match := templateErrRe.FindStringSubmatch(errors.Cause(err).Error())
+	if match == nil {
+		return err
+	}
+
+	pos := token.Position{Filename: templatePath}
+	if name := match[1]; strings.HasPrefix(name, "_") && name != filepath.Base(templatePath) {
+		pos.Filename = filepath.Join(filepath.Dir(templatePath), name)
+	}
+	pos.Line, _ = strconv.Atoi(match[2])
+	pos.Column, _ = strconv.Atoi(match[3])
+	return &parser.Error{Pos: pos, Msg: match[4]}
 }
 
 func FigureOut(spec *parser.StructureSpec) (_ *ModelSpec, err error) {
@@ -49,37 +145,89 @@ func FigureOut(spec *parser.StructureSpec) (_ *ModelSpec, err error) {
 	s := ModelSpec{
 		TypeName:   spec.Name,
 		TypeString: result,
+		TypeRef:    spec.Name,
 	}
 
-	var tagsKV map[string]string
+	var errs parser.ErrorList
 	for _, fieldName := range spec.Fields {
-		tagsKV, err = parseTag(spec.Tags[fieldName])
+		rawTags, err := parseRawTags(spec.Tags[fieldName])
 		if err != nil {
-			return nil, err
+			errs.Add(tagPosition(spec, fieldName, err), fmt.Sprintf("field %s: %v", fieldName, err))
+			continue
 		}
+		tagsKV, tagOpts := sanitizeTags(rawTags)
+		fieldType := spec.Types[fieldName]
 		s.Fields = append(s.Fields, Field{
-			Name:  fieldName,
-			FType: spec.FTypes[fieldName],
-			Tags:  tagsKV,
+			Name:          fieldName,
+			FType:         spec.FTypes[fieldName],
+			TypeRef:       spec.FTypes[fieldName],
+			ImportPath:    fieldType.ImportPath,
+			KeyImportPath: fieldType.KeyImportPath,
+			Basic:         fieldType.Basic,
+			Enum:          fieldType.Enum,
+			Validatable:   fieldType.Validatable,
+			Pointer:       fieldType.Pointer,
+			Slice:         fieldType.Slice,
+			Map:           fieldType.Map,
+			Embedded:      fieldType.Embedded,
+			TagOpts:       tagOpts,
+			Tags:          tagsKV,
+			rawTags:       rawTags,
+			qualified:     fieldType.Qualified,
 		})
 	}
 
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
 	return &s, nil
 }
 
-func parseTag(rawTag string) (map[string]string, error) {
+// tagPosition returns the position of the tag error in the source,
+// or the position of the field, if the field has no tag.
+func tagPosition(spec *parser.StructureSpec, fieldName string, err error) token.Position {
+	pos, ok := spec.TagPos[fieldName]
+	if !ok {
+		return spec.FieldPos[fieldName]
+	}
+
+	if tagErr, ok := err.(*tagError); ok {
+		offset := tagErr.offset
+		if strings.HasPrefix(spec.Tags[fieldName], "`") {
+			offset++
+		}
+		pos.Offset += offset
+		pos.Column += offset
+	}
+	return pos
+}
+
+// tagError is an error of the struct tag syntax at the offset in the tag.
+type tagError struct {
+	offset int
+	msg    string
+}
+
+func (err *tagError) Error() string {
+	return err.msg
+}
+
+// parseTag parses the struct tag by the reflect.StructTag conventions
+// and returns the first values of the keys and their options.
+// The empty tag is valid, keys with the `-` value are omitted.
+func parseTag(rawTag string) (map[string]string, TagOptions, error) {
 	tags, err := parseRawTags(rawTag)
 	if err != nil {
-		return nil, err
-	}
-	if len(tags) == 0 {
-		return nil, errors.New("No tags")
+		return nil, nil, err
 	}
 
-	tags = sanitizeTags(tags)
-	return tags, nil
+	tags, options := sanitizeTags(tags)
+	return tags, options, nil
 }
 
+// parseRawTags parses the tag like reflect.StructTag.Lookup does,
+// but reports the syntax errors with their offsets in the tag.
+// If the key is repeated, the first value is used.
 func parseRawTags(tag string) (map[string]string, error) {
 	tags := map[string]string{}
 
@@ -89,65 +237,92 @@ func parseRawTags(tag string) (map[string]string, error) {
 	const quote = '"'
 	const whitespace = ' '
 
-	var keyFound bool
-	var keyStart, valueStart int
-	var key string
+	i := 0
+	for i < len(tag) {
+		// key:"value" pairs are separated by spaces
+		for i < len(tag) && tag[i] == whitespace {
+			i++
+		}
+		if i == len(tag) {
+			break
+		}
 
-	//todo: add comments
-	for i := range tag {
-		if keyFound && valueStart == i {
-			continue
+		keyStart := i
+		for i < len(tag) && tag[i] != whitespace && tag[i] != kvSeparator && tag[i] != quote {
+			i++
+		}
+		key := tag[keyStart:i]
+		if key == "" {
+			return nil, &tagError{offset: keyStart, msg: "key of the tag is empty"}
+		}
+		for _, r := range key {
+			if unicode.IsControl(r) {
+				return nil, &tagError{offset: keyStart, msg: fmt.Sprintf("invalid key %q", key)}
+			}
+		}
+		if i == len(tag) || tag[i] != kvSeparator {
+			return nil, &tagError{offset: i, msg: fmt.Sprintf("key %q has no value", key)}
 		}
 
-		s := tag[i]
-		if i < 1 && s == kvSeparator {
-			// separator can not be in first position
-			return nil, errors.New("invalid tag")
+		i++
+		if i >= len(tag) || tag[i] != quote {
+			return nil, &tagError{offset: i, msg: fmt.Sprintf("value of the key %q is not quoted", key)}
 		}
 
-		if !keyFound && s == kvSeparator {
-			if tag[i+1] != quote {
-				return nil, errors.New("invalid tag")
-			}
-			key = tag[keyStart:i]
-			invalid, _ := regexp.MatchString("([^a-zA-Z0-9_]+)", key)
-			if invalid {
-				return nil, errors.New("invalid key")
+		// scan the quoted value, it can contain escaped quotes
+		valueStart := i
+		i++
+		for i < len(tag) && tag[i] != quote {
+			if tag[i] == '\\' {
+				i++
 			}
-			valueStart = i + 1
-			keyFound = true
-			continue
+			i++
 		}
+		if i >= len(tag) {
+			return nil, &tagError{offset: valueStart, msg: fmt.Sprintf("value of the key %q is not terminated", key)}
+		}
+		i++
 
-		if keyFound && tag[i] == quote {
-			if i+1 < len(tag) && tag[i+1] != whitespace {
-				return nil, errors.New("invalid tag")
-			}
-			// remove leading and trailing double quotes, if exist
-			tags[key] = strings.Trim(tag[valueStart:i], `"`)
-			keyStart = i + 2
-			keyFound = false
-			continue
+		value, err := strconv.Unquote(tag[valueStart:i])
+		if err != nil {
+			return nil, &tagError{offset: valueStart, msg: fmt.Sprintf("value of the key %q is invalid: %v", key, err)}
+		}
+		if i < len(tag) && tag[i] != whitespace {
+			return nil, &tagError{offset: i,
+				msg: fmt.Sprintf("value of the key %q should be followed by a space", key)}
 		}
 
+		if _, ok := tags[key]; !ok {
+			tags[key] = value
+		}
 	}
 	return tags, nil
 }
 
-func sanitizeTags(tags map[string]string) map[string]string {
-	for key, value := range tags {
-		// remove leading and trailing double quotes, if exist
-		value = strings.Trim(value, `"`)
+// sanitizeTags splits the values of the tags into the first value and options.
+// The raw tags are kept intact.
+func sanitizeTags(raw map[string]string) (map[string]string, TagOptions) {
+	tags := make(map[string]string, len(raw))
+	options := TagOptions{}
+	for key, value := range raw {
 		// tags can have not only values, but also optional parameters,
 		// such as 'omitempty' for JSON, separated by comma;
-		// therefore, we divide the value by comma and take only the first value
-		value = strings.Split(value, ",")[0]
+		// therefore, we divide the value by comma and take the first value
+		parts := strings.Split(value, ",")
 		// special case for hidden fields
-		if value == "-" {
-			delete(tags, key)
+		if parts[0] == "-" {
 			continue
 		}
-		tags[key] = value
+		tags[key] = parts[0]
+
+		for _, option := range parts[1:] {
+			if option = strings.TrimSpace(option); option != "" {
+				if options[key] == nil {
+					options[key] = map[string]bool{}
+				}
+				options[key][option] = true
+			}
+		}
 	}
-	return tags
+	return tags, options
 }
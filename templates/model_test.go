@@ -1,15 +1,21 @@
 package templates
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"go/token"
 	"testing"
+	"text/template"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/lancer-kit/forge/parser"
 )
 
 func Test_parseTag(t *testing.T) {
 	for _, value := range testDataStructTag {
-		res, err := parseTag(value.tag)
+		res, _, err := parseTag(value.tag)
 		t.Run("check for tag=>"+value.tag, func(t *testing.T) {
 			if value.throwError {
 				if err == nil {
@@ -105,7 +111,7 @@ var testDataStructTag = []struct {
 	}, // note multiple colons.
 	{
 		tag:        "k0:\"values contain spaces\" k1:\"literal\ttabs\" k2:\"and\\tescaped\\tabs\"",
-		result:     map[string]string{"k0": "values contain spaces", "k1": "literal\ttabs", "k2": "and\\tescaped\\tabs"},
+		result:     map[string]string{"k0": "values contain spaces", "k1": "literal\ttabs", "k2": "and\tescaped\tabs"},
 		throwError: false,
 	},
 	{
@@ -214,3 +220,57 @@ var testDataStructTag = []struct {
 	//	throwError: true,
 	//}, // want "not compatible with reflect.StructTag.Get: bad syntax for struct tag value"
 }
+
+func Test_parseTagOptions(t *testing.T) {
+	tags, options, err := parseTag("`db:\"id,pk, readonly\" json:\"-\" yaml:\"a\\\"b,omitempty\" db:\"other\"`")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"db": "id", "yaml": `a"b`}, tags)
+	assert.Equal(t, TagOptions{"db": {"pk": true, "readonly": true}, "yaml": {"omitempty": true}}, options)
+
+	tags, options, err = parseTag("")
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+	assert.Empty(t, options)
+}
+
+func Test_parseRawTagsErrors(t *testing.T) {
+	cases := []struct {
+		tag    string
+		offset int
+		msg    string
+	}{
+		{tag: `:"emptykey"`, offset: 0, msg: "key of the tag is empty"},
+		{tag: `db:id`, offset: 3, msg: `value of the key "db" is not quoted`},
+		{tag: `db:`, offset: 3, msg: `value of the key "db" is not quoted`},
+		{tag: "json:\"id\" d\bb:\"id\"", offset: 10, msg: `invalid key "d\bb"`},
+		{tag: `json:"id" db`, offset: 12, msg: `key "db" has no value`},
+		{tag: `x:"\q"`, offset: 2, msg: `value of the key "x" is invalid: invalid syntax`},
+		{tag: `x:"foo"y:"bar"`, offset: 7, msg: `value of the key "x" should be followed by a space`},
+		{tag: `x:"noEndQuote`, offset: 2, msg: `value of the key "x" is not terminated`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.tag, func(t *testing.T) {
+			_, err := parseRawTags(tc.tag)
+			assert.Equal(t, &tagError{offset: tc.offset, msg: tc.msg}, err)
+		})
+	}
+}
+
+func TestTemplateError(t *testing.T) {
+	_, err := template.New("t.tmpl").Parse("line\n{{ .Name | unknown }}")
+	assert.Equal(t, &parser.Error{
+		Pos: token.Position{Filename: "tmpl/t.tmpl", Line: 2},
+		Msg: `function "unknown" not defined`,
+	}, TemplateError("tmpl/t.tmpl", err))
+
+	tmpl := template.Must(template.New("t.tmpl").Parse("{{ .Name }}\n  {{ .Unknown }}"))
+	err = tmpl.Execute(&bytes.Buffer{}, struct{ Name string }{})
+	assert.Equal(t, &parser.Error{
+		Pos: token.Position{Filename: "tmpl/t.tmpl", Line: 2, Column: 5},
+		Msg: `executing "t.tmpl" at <.Unknown>: can't evaluate field Unknown in type struct { Name string }`,
+	}, TemplateError("tmpl/t.tmpl", err))
+
+	notTemplate := errors.New("open t.tmpl: no such file or directory")
+	assert.Equal(t, notTemplate, TemplateError("t.tmpl", notTemplate))
+}
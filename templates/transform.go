@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/fatih/camelcase"
+
+	"github.com/lancer-kit/forge/parser"
 )
 
 type TransformRule string
@@ -58,19 +60,21 @@ func transformString(src, delimiter string) string {
 	return result
 }
 
-func (rule TransformRule) TransformValues(typeName string, values []string, keepTPrefix bool) []TypeValue {
+func (rule TransformRule) TransformValues(typeName string, values []parser.Value, keepTPrefix bool) []TypeValue {
 	var str string
 	res := make([]TypeValue, len(values))
 
 	for i := range values {
-		str = values[i]
+		str = values[i].Name
 		if !keepTPrefix {
 			str = strings.Replace(str, typeName, "", 1)
 		}
 
 		res[i] = TypeValue{
-			Name: values[i],
-			Str:  rule.Transform(str),
+			Name:  values[i].Name,
+			Str:   rule.Transform(str),
+			Alias: values[i].AliasOf != "",
+			GQL:   GraphQLName(str),
 		}
 	}
 	return res
@@ -0,0 +1,257 @@
+package templates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IsRule is a rule of the `validate` tag implemented by the ozzo-validation/is package.
+type IsRule struct {
+	// Expr is the Go expression of the rule.
+	Expr string
+	// Format and Pattern describe the valid strings in the JSON schema, if they can.
+	Format, Pattern string
+}
+
+// isRules are the rules of the ozzo-validation/is package by their names.
+var isRules = map[string]IsRule{
+	"email":        {Expr: "is.Email", Format: "email"},
+	"url":          {Expr: "is.URL", Format: "uri"},
+	"uuid":         {Expr: "is.UUID", Format: "uuid"},
+	"ip":           {Expr: "is.IP"},
+	"alpha":        {Expr: "is.Alpha", Pattern: "^[a-zA-Z]+$"},
+	"alphanumeric": {Expr: "is.Alphanumeric", Pattern: "^[a-zA-Z0-9]+$"},
+	"digit":        {Expr: "is.Digit", Pattern: "^[0-9]+$"},
+}
+
+// ValidateRule is a rule of the `validate` tag, like `length=3:64`.
+type ValidateRule struct {
+	Name string
+	// Args are the arguments of the rule: the bounds of `length` (empty one is no limit),
+	// the value of `min` and `max`, the items of `in` and the expression of `match`.
+	Args []string
+	// Is is set for the rules of the ozzo-validation/is package.
+	Is *IsRule
+}
+
+// ParseValidateTag parses the rules of the `validate` tag, like `required,length=3:64,email`,
+// in order of the tag. The expression of `match` may contain commas: the following parts
+// of the tag belong to it up to the next rule. The values of `min`, `max` and `in`
+// are not checked, as they depend on the type of the field.
+func ParseValidateTag(tag string) ([]ValidateRule, error) {
+	var items []string
+	for _, item := range strings.Split(tag, ",") {
+		if n := len(items); n > 0 && strings.HasPrefix(items[n-1], "match=") && !startsRule(item) {
+			items[n-1] += "," + item
+			continue
+		}
+		items = append(items, item)
+	}
+
+	var rules []ValidateRule
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		rule, err := parseValidateRule(item)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// startsRule reports whether the part of the tag is a rule, like `email` or `length=3:64`.
+func startsRule(item string) bool {
+	name := item
+	if i := strings.Index(item, "="); i >= 0 {
+		name = item[:i]
+	}
+	if _, ok := isRules[name]; ok {
+		return true
+	}
+	switch name {
+	case "required", "notnil", "length", "min", "max", "in", "match":
+		return true
+	}
+	return false
+}
+
+// parseValidateRule parses the rule like `length=3:64`.
+func parseValidateRule(rule string) (ValidateRule, error) {
+	name, arg := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+
+	if name != "length" && name != "min" && name != "max" && name != "in" && name != "match" && arg != "" {
+		return ValidateRule{}, fmt.Errorf("rule %s has no arguments", name)
+	}
+	result := ValidateRule{Name: name}
+	if is, ok := isRules[name]; ok {
+		result.Is = &is
+		return result, nil
+	}
+
+	switch name {
+	case "required", "notnil":
+	case "length":
+		bounds := strings.Split(arg, ":")
+		if len(bounds) != 2 {
+			return ValidateRule{}, fmt.Errorf("length: should be like length=3:64, length=:64 or length=3:")
+		}
+		for _, bound := range bounds {
+			if _, err := strconv.ParseUint(bound, 10, 32); bound != "" && err != nil {
+				return ValidateRule{}, fmt.Errorf("length: %q is not a number", bound)
+			}
+		}
+		result.Args = bounds
+	case "min", "max", "match":
+		if name == "match" && arg == "" {
+			return ValidateRule{}, fmt.Errorf("match: regular expression should be set")
+		}
+		result.Args = []string{arg}
+	case "in":
+		result.Args = strings.Split(arg, "|")
+	default:
+		return ValidateRule{}, fmt.Errorf("unknown rule %q", name)
+	}
+	return result, nil
+}
+
+// validationRules returns the ozzo-validation rules of the field declared by its `validate` tag,
+// like `validate:"required,length=3:64,email"`, as Go expressions in order of the tag.
+// With the prefix the `match` rules refer to the package variables declared by validationPatterns
+// with the same prefix, otherwise the regular expressions are compiled in place.
+func validationRules(field Field, prefix ...string) ([]string, error) {
+	rules := []string{}
+	parsed, err := fieldValidateRules(field)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := 0
+	for _, rule := range parsed {
+		var expr string
+		if rule.Name == "match" && len(prefix) > 0 {
+			expr = fmt.Sprintf("validation.Match(%s)", patternName(prefix[0], field, matches))
+			matches++
+		} else if expr, err = validationRule(field, rule); err != nil {
+			return nil, fmt.Errorf("field %s: %v", field.Name, err)
+		}
+		rules = append(rules, expr)
+	}
+	return rules, nil
+}
+
+// ValidationPattern is the package variable of the regular expression of the `match` rule,
+// so it's compiled once rather than on every validation.
+type ValidationPattern struct {
+	// Name is the name of the variable and Expr is its value, the compiled expression.
+	Name, Expr string
+}
+
+// validationPatterns returns the variables of the `match` rules of the field,
+// they are named by the prefix, the field and the number of the rule.
+func validationPatterns(field Field, prefix string) ([]ValidationPattern, error) {
+	parsed, err := fieldValidateRules(field)
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []ValidationPattern
+	for _, rule := range parsed {
+		if rule.Name != "match" {
+			continue
+		}
+		patterns = append(patterns, ValidationPattern{
+			Name: patternName(prefix, field, len(patterns)),
+			Expr: fmt.Sprintf("regexp.MustCompile(%s)", strconv.Quote(rule.Args[0])),
+		})
+	}
+	return patterns, nil
+}
+
+// fieldValidateRules parses the `validate` tag of the field.
+func fieldValidateRules(field Field) ([]ValidateRule, error) {
+	if !hasTag(field, "validate") {
+		return nil, nil
+	}
+	rules, err := ParseValidateTag(field.rawTags["validate"])
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %v", field.Name, err)
+	}
+	return rules, nil
+}
+
+// patternName returns the name of the variable of the i-th `match` rule of the field,
+// like `userNamePattern`, the following ones are numbered from 2.
+func patternName(prefix string, field Field, i int) string {
+	name := prefix + field.Name + "Pattern"
+	if i > 0 {
+		name += strconv.Itoa(i + 1)
+	}
+	return name
+}
+
+// validationRule returns the Go expression of the rule for the field.
+func validationRule(field Field, rule ValidateRule) (string, error) {
+	if rule.Is != nil {
+		return rule.Is.Expr, nil
+	}
+
+	switch rule.Name {
+	case "required":
+		return "validation.Required", nil
+	case "notnil":
+		return "validation.NotNil", nil
+	case "length":
+		bounds := make([]string, len(rule.Args))
+		for i, bound := range rule.Args {
+			bounds[i] = bound
+			if bound == "" {
+				bounds[i] = "0"
+			}
+		}
+		return fmt.Sprintf("validation.Length(%s, %s)", bounds[0], bounds[1]), nil
+	case "min", "max":
+		value, err := ruleValue(field, rule.Args[0])
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", rule.Name, err)
+		}
+		return fmt.Sprintf("validation.%s(%s)", strings.Title(rule.Name), value), nil
+	case "in":
+		var values []string
+		for _, item := range rule.Args {
+			value, err := ruleValue(field, item)
+			if err != nil {
+				return "", fmt.Errorf("in: %v", err)
+			}
+			values = append(values, value)
+		}
+		return fmt.Sprintf("validation.In(%s)", strings.Join(values, ", ")), nil
+	default:
+		return fmt.Sprintf("validation.Match(regexp.MustCompile(%s))", strconv.Quote(rule.Args[0])), nil
+	}
+}
+
+// ruleValue returns the Go expression of the value of the field type,
+// rules compare the values of the same kind only.
+func ruleValue(field Field, value string) (string, error) {
+	typ := strings.TrimPrefix(field.TypeRef, "*")
+	switch {
+	case typ == "string":
+		return strconv.Quote(value), nil
+	case field.Basic == "string":
+		return typ + "(" + strconv.Quote(value) + ")", nil
+	case field.Basic == "" || field.Basic == "bool":
+		return "", fmt.Errorf("type %s is not supported", field.FType)
+	}
+
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return "", fmt.Errorf("%q is not a number", value)
+	}
+	return typ + "(" + value + ")", nil
+}
@@ -0,0 +1,94 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validationRules(t *testing.T) {
+	field := func(ftype, basic, tag string) Field {
+		raw, err := parseRawTags("`validate:\"" + tag + "\"`")
+		require.NoError(t, err)
+		tags, opts := sanitizeTags(raw)
+		return Field{Name: "F", FType: ftype, TypeRef: ftype, Basic: basic, Tags: tags, TagOpts: opts, rawTags: raw}
+	}
+
+	for _, c := range []struct {
+		field Field
+		rules []string
+	}{
+		{field("string", "string", "email,required,length=3:64"),
+			[]string{"is.Email", "validation.Required", "validation.Length(3, 64)"}},
+		{field("*int64", "int64", "min=1,max=10"),
+			[]string{"validation.Min(int64(1))", "validation.Max(int64(10))"}},
+		{field("Role", "string", "in=admin|user"),
+			[]string{`validation.In(Role("admin"), Role("user"))`}},
+		{field("string", "string", "match=^[a-z]+$,length=:8"),
+			[]string{`validation.Match(regexp.MustCompile("^[a-z]+$"))`, "validation.Length(0, 8)"}},
+		{field("string", "string", "required,match=^[a-z]{2,5}(,[a-z]+)*$,email"),
+			[]string{"validation.Required", `validation.Match(regexp.MustCompile("^[a-z]{2,5}(,[a-z]+)*$"))`, "is.Email"}},
+		{Field{Name: "F", FType: "int", TypeRef: "int"}, []string{}},
+	} {
+		rules, err := validationRules(c.field)
+		require.NoError(t, err)
+		assert.Equal(t, c.rules, rules)
+	}
+
+	for tag, msg := range map[string]string{
+		"length=3":   "field F: length: should be like length=3:64, length=:64 or length=3:",
+		"min=a":      `field F: min: "a" is not a number`,
+		"email=true": "field F: rule email has no arguments",
+		"unknown":    `field F: unknown rule "unknown"`,
+	} {
+		_, err := validationRules(field("int", "int", tag))
+		assert.EqualError(t, err, msg, tag)
+	}
+
+	_, err := validationRules(field("time.Time", "", "min=1"))
+	assert.EqualError(t, err, "field F: min: type time.Time is not supported")
+}
+
+func Test_validationPatterns(t *testing.T) {
+	tag := "match=^[a-z]+$,length=:8,match=^[^x]"
+	field := Field{Name: "Name", FType: "string", TypeRef: "string", Basic: "string",
+		Tags: map[string]string{"validate": tag}, rawTags: map[string]string{"validate": tag}}
+
+	patterns, err := validationPatterns(field, "user")
+	require.NoError(t, err)
+	assert.Equal(t, []ValidationPattern{
+		{Name: "userNamePattern", Expr: `regexp.MustCompile("^[a-z]+$")`},
+		{Name: "userNamePattern2", Expr: `regexp.MustCompile("^[^x]")`},
+	}, patterns)
+
+	rules, err := validationRules(field, "user")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"validation.Match(userNamePattern)", "validation.Length(0, 8)", "validation.Match(userNamePattern2)"}, rules)
+
+	patterns, err = validationPatterns(Field{Name: "F", FType: "int", TypeRef: "int"}, "user")
+	require.NoError(t, err)
+	assert.Empty(t, patterns)
+}
+
+func TestParseValidateTag(t *testing.T) {
+	rules, err := ParseValidateTag("required,length=:64,in=a|b,email")
+	require.NoError(t, err)
+	assert.Equal(t, []ValidateRule{
+		{Name: "required"},
+		{Name: "length", Args: []string{"", "64"}},
+		{Name: "in", Args: []string{"a", "b"}},
+		{Name: "email", Is: &IsRule{Expr: "is.Email", Format: "email"}},
+	}, rules)
+
+	// the commas of the match expression don't split it
+	rules, err = ParseValidateTag("match=^a{2,5}$,min=1")
+	require.NoError(t, err)
+	assert.Equal(t, []ValidateRule{
+		{Name: "match", Args: []string{"^a{2,5}$"}},
+		{Name: "min", Args: []string{"1"}},
+	}, rules)
+
+	_, err = ParseValidateTag("required,match=")
+	assert.EqualError(t, err, "match: regular expression should be set")
+}